@@ -0,0 +1,88 @@
+package frac
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var ErrInvalid = errors.New("invalid argument")
+
+// Approximate returns the fraction closest to x with a denominator of at most
+// maxDen, and how far it is from x (the fraction minus x). It uses continued
+// fractions: the convergents of x are the best approximations, and when the
+// next one's denominator is too big, the semiconvergent in between might
+// still be closer than the last convergent.
+func Approximate(x float64, maxDen int) (Frac, float64, error) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return Frac{}, 0, fmt.Errorf("%f: %w", x, ErrInvalid)
+	}
+	if maxDen < 1 {
+		return Frac{}, 0, fmt.Errorf("max denominator %d: %w", maxDen, ErrInvalid)
+	}
+	// the numerator can get up to x * maxDen
+	if math.Abs(x)*float64(maxDen) >= 1<<53 || math.Abs(x) >= float64(maxInt) {
+		return Frac{}, 0, fmt.Errorf("%f with denominators up to %d: %w", x, maxDen, ErrOverflow)
+	}
+
+	// h/k are the convergents, h0/k0 the one before h1/k1
+	h0, k0 := 0, 1
+	h1, k1 := 1, 0
+	r := x
+	// a float64 doesn't have that many terms, it's just in case
+	for i := 0; i < 64; i++ {
+		a := math.Floor(r)
+		h2, k2 := int(a)*h1+h0, int(a)*k1+k0
+		if k2 > maxDen {
+			// the largest semiconvergent which still fits
+			m := (maxDen - k0) / k1
+			semi := raw(m*h1+h0, m*k1+k0)
+			last := raw(h1, k1)
+			if math.Abs(semi.Float()-x) < math.Abs(last.Float()-x) {
+				return result(semi, x)
+			}
+			return result(last, x)
+		}
+		h0, k0, h1, k1 = h1, k1, h2, k2
+		if r == a {
+			break
+		}
+		r = 1 / (r - a)
+	}
+	return result(raw(h1, k1), x)
+}
+
+// Snap returns the fraction closest to x which is a multiple of 1/d, for any d
+// of the grid (so {1, 2, 3, 4, 6, 8} allows halves, triplets, quarters, ...),
+// and how far it is from x (the fraction minus x). If two are as close, the
+// simplest one wins.
+func Snap(x float64, grid []int) (Frac, float64, error) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return Frac{}, 0, fmt.Errorf("%f: %w", x, ErrInvalid)
+	}
+	if len(grid) == 0 {
+		return Frac{}, 0, fmt.Errorf("empty grid: %w", ErrInvalid)
+	}
+	var best Frac
+	bestErr := math.Inf(1)
+	for _, d := range grid {
+		if d < 1 {
+			return Frac{}, 0, fmt.Errorf("subdivision %d: %w", d, ErrInvalid)
+		}
+		n := math.Round(x * float64(d))
+		if math.Abs(n) >= float64(maxInt) {
+			return Frac{}, 0, fmt.Errorf("%f on a grid of %d: %w", x, d, ErrOverflow)
+		}
+		f := raw(int(n), d).Simplify()
+		e := math.Abs(f.Float() - x)
+		if e < bestErr || (e == bestErr && f.Den() < best.Den()) {
+			best, bestErr = f, e
+		}
+	}
+	return result(best, x)
+}
+
+func result(f Frac, x float64) (Frac, float64, error) {
+	f = f.Simplify()
+	return f, f.Float() - x, nil
+}
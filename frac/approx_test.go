@@ -0,0 +1,119 @@
+package frac
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestApproximate(t *testing.T) {
+	var rows = []struct {
+		x        float64
+		maxDen   int
+		expected Frac
+	}{
+		{0.5, 100, F(1, 2)},
+		{0.375, 100, F(3, 8)},
+		{0.333, 10, F(1, 3)},
+		{0.333, 1000, F(333, 1000)},
+		{math.Pi, 10, F(22, 7)},
+		{math.Pi, 100, F(311, 99)},
+		{math.Pi, 1000, F(355, 113)},
+		{-math.Pi, 1000, F(-355, 113)},
+		{2, 5, N(2)},
+		{0, 5, N(0)},
+		{1.49, 1, N(1)},
+		{1.51, 1, N(2)},
+		// the semiconvergent 3/5 is closer than the convergent 1/2
+		{0.6, 6, F(3, 5)},
+		// 2.9987 ticks: 3 is closer than 5/2 or 8/3
+		{2.9987, 4, N(3)},
+		{0.1, 7, F(1, 7)},
+	}
+	for _, row := range rows {
+		actual, e, err := Approximate(row.x, row.maxDen)
+		if err != nil || actual != row.expected {
+			t.Errorf("%f (max %d), actual: %v (%v), expected: %v", row.x, row.maxDen, actual, err, row.expected)
+			continue
+		}
+		if math.Abs(e-(row.expected.Float()-row.x)) > 1e-12 {
+			t.Errorf("%f (max %d) error, actual: %g, expected: %g", row.x, row.maxDen, e, row.expected.Float()-row.x)
+		}
+		if actual.Den() > row.maxDen {
+			t.Errorf("%f (max %d), denominator too big: %v", row.x, row.maxDen, actual)
+		}
+	}
+
+	// check against brute force
+	for _, x := range []float64{0.123456, 0.7071, 1.4142135, 2.718281828, 0.9999, -0.4} {
+		for maxDen := 1; maxDen <= 50; maxDen++ {
+			actual, _, err := Approximate(x, maxDen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			best := math.Inf(1)
+			for d := 1; d <= maxDen; d++ {
+				if e := math.Abs(math.Round(x*float64(d))/float64(d) - x); e < best {
+					best = e
+				}
+			}
+			if e := math.Abs(actual.Float() - x); e > best+1e-15 {
+				t.Errorf("%f (max %d), actual: %v (off by %g), expected something off by %g", x, maxDen, actual, e, best)
+			}
+		}
+	}
+
+	for _, row := range []struct {
+		x      float64
+		maxDen int
+		err    error
+	}{
+		{math.NaN(), 10, ErrInvalid},
+		{math.Inf(1), 10, ErrInvalid},
+		{0.5, 0, ErrInvalid},
+		{1e300, 10, ErrOverflow},
+	} {
+		if _, _, err := Approximate(row.x, row.maxDen); !errors.Is(err, row.err) {
+			t.Errorf("%f (max %d), actual: %v, expected: %v", row.x, row.maxDen, err, row.err)
+		}
+	}
+}
+
+func TestSnap(t *testing.T) {
+	grid := []int{1, 2, 3, 4, 6, 8}
+	var rows = []struct {
+		x        float64
+		expected Frac
+	}{
+		{0.5, F(1, 2)},
+		{0.49, F(1, 2)},
+		{0.34, F(1, 3)},
+		{0.3, F(1, 3)},
+		{0.13, F(1, 8)},
+		{0.2, F(1, 6)},
+		{2.01, N(2)},
+		{-0.26, F(-1, 4)},
+		// halfway between 1/8 and 1/6, the smaller denominator wins
+		{(1.0/8 + 1.0/6) / 2, F(1, 6)},
+	}
+	for _, row := range rows {
+		actual, e, err := Snap(row.x, grid)
+		if err != nil || actual != row.expected {
+			t.Errorf("%f, actual: %v (%v), expected: %v", row.x, actual, err, row.expected)
+			continue
+		}
+		if math.Abs(e-(row.expected.Float()-row.x)) > 1e-12 {
+			t.Errorf("%f error, actual: %g, expected: %g", row.x, e, row.expected.Float()-row.x)
+		}
+	}
+
+	if _, _, err := Snap(0.5, nil); !errors.Is(err, ErrInvalid) {
+		t.Errorf("empty grid, actual: %v, expected: %v", err, ErrInvalid)
+	}
+	if _, _, err := Snap(0.5, []int{4, 0}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("0 in grid, actual: %v, expected: %v", err, ErrInvalid)
+	}
+	if _, _, err := Snap(math.NaN(), grid); !errors.Is(err, ErrInvalid) {
+		t.Errorf("NaN, actual: %v, expected: %v", err, ErrInvalid)
+	}
+}
@@ -0,0 +1,175 @@
+package frac
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrOverflow means that the numerator or the denominator doesn't fit in an
+// int. Intermediate results can be bigger than that (they are computed with
+// big.Rat), it's only the simplified result which has to fit. If you really
+// need bigger numbers, carry on with Big (a.Big().Add(b.Big())).
+var ErrOverflow = errors.New("overflow")
+
+const (
+	maxInt = int(^uint(0) >> 1)
+	minInt = -maxInt - 1
+)
+
+// Rat returns f as a big.Rat (which can't overflow)
+func (f Frac) Rat() *big.Rat {
+	f = f.Simplify()
+	return big.NewRat(int64(f.num), int64(f.Den()))
+}
+
+// FromRat returns the fraction, or ErrOverflow if it's too big to fit in a
+// Frac
+func FromRat(r *big.Rat) (Frac, error) {
+	num, den := r.Num(), r.Denom()
+	if !num.IsInt64() || !den.IsInt64() {
+		return Frac{}, fmt.Errorf("%s: %w", r.RatString(), ErrOverflow)
+	}
+	n, d := num.Int64(), den.Int64()
+	// int might only be 32 bits
+	if n > int64(maxInt) || n < int64(minInt) || d > int64(maxInt) {
+		return Frac{}, fmt.Errorf("%s: %w", r.RatString(), ErrOverflow)
+	}
+	// big.Rat is already simplified, with a positive denominator
+	return raw(int(n), int(d)), nil
+}
+
+// CheckedMultiply is like Multiply, but returns ErrOverflow instead of
+// panicking
+func (a Frac) CheckedMultiply(b Frac) (Frac, error) {
+	// fast path: most of the time, nothing is anywhere near overflowing
+	if num, ok := mul(a.num, b.num); ok {
+		if den, ok := mul(a.Den(), b.Den()); ok && den != 0 {
+			return raw(num, den).Simplify(), nil
+		}
+	}
+	return FromRat(new(big.Rat).Mul(a.Rat(), b.Rat()))
+}
+
+// CheckedAdd is like Add, but returns ErrOverflow instead of panicking
+func (a Frac) CheckedAdd(b Frac) (Frac, error) {
+	a, b = a.Simplify(), b.Simplify()
+	if x, ok := mul(a.num, b.Den()); ok {
+		if y, ok := mul(b.num, a.Den()); ok {
+			if den, ok := mul(a.Den(), b.Den()); ok {
+				if num, ok := add(x, y); ok {
+					return raw(num, den).Simplify(), nil
+				}
+			}
+		}
+	}
+	return FromRat(new(big.Rat).Add(a.Rat(), b.Rat()))
+}
+
+// CheckedMinus is like Minus, but returns ErrOverflow instead of panicking
+func (a Frac) CheckedMinus(b Frac) (Frac, error) {
+	b = b.Simplify()
+	// -minInt doesn't fit in an int
+	if b.num != minInt {
+		return a.CheckedAdd(raw(-b.num, b.Den()))
+	}
+	return FromRat(new(big.Rat).Sub(a.Rat(), b.Rat()))
+}
+
+func must(f Frac, err error) Frac {
+	if err != nil {
+		panic(fmt.Sprintf("%s (if you don't want a panic, use the Checked functions)", err))
+	}
+	return f
+}
+
+// mul returns a * b, and false if it overflows
+func mul(a, b int) (int, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	if c/b != a || (a == -1 && b == minInt) || (b == -1 && a == minInt) {
+		return 0, false
+	}
+	return c, true
+}
+
+// add returns a + b, and false if it overflows
+func add(a, b int) (int, bool) {
+	c := a + b
+	if (b > 0 && c < a) || (b < 0 && c > a) {
+		return 0, false
+	}
+	return c, true
+}
+
+// Big is a fraction backed by a big.Rat, for when the numbers don't fit in a
+// Frac anymore (see ErrOverflow). It's slower, and it isn't comparable with ==
+// (that's why Frac doesn't switch to it on its own: Piece.Equal compares
+// notes with ==), so use Equal or Compare. It's immutable too, and the zero
+// value is 0.
+type Big struct {
+	r *big.Rat
+}
+
+// Big returns f as a Big
+func (f Frac) Big() Big {
+	return Big{f.Rat()}
+}
+
+// rat never returns nil, so that the zero value works. Don't modify it!
+func (b Big) rat() *big.Rat {
+	if b.r == nil {
+		return new(big.Rat)
+	}
+	return b.r
+}
+
+// Frac returns b as a Frac, or ErrOverflow if it still doesn't fit
+func (b Big) Frac() (Frac, error) {
+	return FromRat(b.rat())
+}
+
+// Rat returns a copy of the big.Rat
+func (b Big) Rat() *big.Rat {
+	return new(big.Rat).Set(b.rat())
+}
+
+func (b Big) String() string {
+	return b.rat().String()
+}
+
+func (b Big) Float() float64 {
+	f, _ := b.rat().Float64()
+	return f
+}
+
+func (a Big) Add(b Big) Big {
+	return Big{new(big.Rat).Add(a.rat(), b.rat())}
+}
+
+func (a Big) Minus(b Big) Big {
+	return Big{new(big.Rat).Sub(a.rat(), b.rat())}
+}
+
+func (a Big) Multiply(b Big) Big {
+	return Big{new(big.Rat).Mul(a.rat(), b.rat())}
+}
+
+// Divide returns a / b, or ErrZeroDivision if b is 0
+func (a Big) Divide(b Big) (Big, error) {
+	if b.rat().Sign() == 0 {
+		return Big{}, ErrZeroDivision
+	}
+	return Big{new(big.Rat).Quo(a.rat(), b.rat())}, nil
+}
+
+// Compare returns -1 if a < b, 0 if a == b and 1 if a > b
+func (a Big) Compare(b Big) int {
+	return a.rat().Cmp(b.rat())
+}
+
+func (a Big) Equal(b Big) bool {
+	return a.Compare(b) == 0
+}
@@ -1,25 +1,39 @@
 package frac
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 )
 
 var ErrZeroDivision = errors.New("divide by zero")
 
-// Frac is an immutable number
+// Frac is an immutable number. The zero value is 0 (0/1), the same as N(0)
 type Frac struct {
 	num int
-	den int
+	// the denominator minus one, so that the zero value is 0/1 and not 0/0
+	denm1 int
+}
+
+// raw returns num/den as is, without simplifying it
+func raw(num, den int) Frac {
+	return Frac{num, den - 1}
 }
 
 // NewFrac returns a fraction, or an error if the denominator is 0
+// (ErrZeroDivision) or if the simplified fraction doesn't fit in an int
+// (ErrOverflow, only for -minInt)
 func NewFrac(num int, den int) (Frac, error) {
 	if den == 0 {
 		return Frac{}, ErrZeroDivision
 	}
-	return Frac{num, den}.Simplify(), nil
+	if num == minInt || den == minInt {
+		// the sign or the gcd could make it overflow
+		return FromRat(big.NewRat(int64(num), int64(den)))
+	}
+	return raw(num, den).Simplify(), nil
 }
 
 // F returns a new fraction and panics if den is 0!
@@ -34,7 +48,7 @@ func F(num int, den int) Frac {
 
 // N returns a new fraction with denominator one.
 func N(num int) Frac {
-	return Frac{num, 1}
+	return raw(num, 1)
 }
 
 func (f Frac) String() string {
@@ -42,39 +56,88 @@ func (f Frac) String() string {
 }
 
 func (f Frac) Float() float64 {
-	return float64(f.num) / float64(f.den)
+	return float64(f.num) / float64(f.Den())
 }
 
+// Simplify divides by the gcd, and puts the sign on the numerator (the
+// denominator is always positive). Every function returning a Frac simplifies
+// it, so == works
 func (f Frac) Simplify() Frac {
-	// FIXME: what happens with negative numbers?
-	k := gcd(f.Num(), f.Den())
-	return Frac{f.Num() / k, f.Den() / k}
+	den := f.Den()
+	if den == 0 {
+		// NewFrac doesn't allow 0 as a denominator
+		return N(0)
+	}
+	k := gcd(abs(f.num), abs(den))
+	if den < 0 {
+		k = -k
+	}
+	return raw(f.num/k, den/k)
 }
 
+// MarshalJSON writes an array: [numerator, denominator]. The zero value is
+// written as [0, 1], so that it can be read back
 func (f Frac) MarshalJSON() ([]byte, error) {
-	return json.Marshal([2]int{f.num, f.den})
+	f = f.Simplify()
+	return json.Marshal([2]int{f.num, f.Den()})
 }
 
+// UnmarshalJSON reads the array MarshalJSON writes ([3, 4]), a string
+// (anything Parse accepts, like "3/4" or "1 1/2") or an integer. The
+// denominator can't be 0
 func (f *Frac) UnmarshalJSON(data []byte) error {
-	var numbers []int
-	if err := json.Unmarshal(data, &numbers); err != nil {
-		return err
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		// like the standard types, null doesn't change anything
+		return nil
+	}
+
+	switch data[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("%s: %w", err, ErrParsing)
+		}
+		parsed, err := Parse(s)
+		if err != nil {
+			return err
+		}
+		*f = parsed
+		return nil
+	case '[':
+		var numbers []int
+		if err := json.Unmarshal(data, &numbers); err != nil {
+			return fmt.Errorf("%s: expected [numerator, denominator] (integers): %w", data, ErrParsing)
+		}
+		if len(numbers) != 2 {
+			return fmt.Errorf("%s: expected [numerator, denominator], got %d numbers: %w", data, len(numbers), ErrParsing)
+		}
+		parsed, err := NewFrac(numbers[0], numbers[1])
+		if err != nil {
+			return fmt.Errorf("%s: %w", data, err)
+		}
+		*f = parsed
+		return nil
+	default:
+		var n int
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("%s: expected an array, a string or an integer: %w", data, ErrParsing)
+		}
+		*f = N(n)
+		return nil
 	}
-	f.num = numbers[0]
-	f.den = numbers[1]
-	return nil
 }
 
 func (f Frac) Abs() Frac {
 	num := f.num
-	den := f.den
+	den := f.Den()
 	if num < 0 {
 		num *= -1
 	}
 	if den < 0 {
 		den *= -1
 	}
-	return Frac{num, den}
+	return raw(num, den)
 }
 
 func (f Frac) Num() int {
@@ -82,25 +145,89 @@ func (f Frac) Num() int {
 }
 
 func (f Frac) Den() int {
-	return f.den
+	return f.denm1 + 1
 }
 
+// Multiply panics with ErrOverflow if the result doesn't fit in an int (see
+// CheckedMultiply). Same for Add and Minus. Like F, only use them with numbers
+// you know are small (no user data!), otherwise use the Checked functions.
 func (a Frac) Multiply(b Frac) Frac {
-	return Frac{
-		num: a.Num() * b.Num(),
-		den: a.Den() * b.Den(),
-	}.Simplify()
+	return must(a.CheckedMultiply(b))
 }
 
 func (a Frac) Add(b Frac) Frac {
-	return Frac{
-		num: a.Num()*b.Den() + b.Num()*a.Den(),
-		den: a.Den() * b.Den(),
-	}.Simplify()
+	return must(a.CheckedAdd(b))
 }
 
 func (a Frac) Minus(b Frac) Frac {
-	return a.Add(b.Multiply(Frac{-1, 1})).Simplify()
+	return must(a.CheckedMinus(b))
+}
+
+// Divide returns a / b, or an error if b is 0 (ErrZeroDivision) or if the
+// result doesn't fit in an int (ErrOverflow)
+func (a Frac) Divide(b Frac) (Frac, error) {
+	if b.num == 0 {
+		return Frac{}, ErrZeroDivision
+	}
+	return FromRat(new(big.Rat).Quo(a.Rat(), b.Rat()))
+}
+
+// Compare returns -1 if a < b, 0 if a == b and 1 if a > b
+func (a Frac) Compare(b Frac) int {
+	a = a.Simplify()
+	b = b.Simplify()
+	// the denominators are positive, so it doesn't change the order
+	lhs, ok1 := mul(a.Num(), b.Den())
+	rhs, ok2 := mul(b.Num(), a.Den())
+	if !ok1 || !ok2 {
+		return a.Rat().Cmp(b.Rat())
+	}
+	if lhs < rhs {
+		return -1
+	} else if lhs > rhs {
+		return 1
+	}
+	return 0
+}
+
+func (a Frac) Less(b Frac) bool {
+	return a.Compare(b) < 0
+}
+
+// Floor returns the largest integer which isn't greater than f
+func (f Frac) Floor() int {
+	f = f.Simplify()
+	q := f.num / f.Den()
+	if f.num%f.Den() != 0 && f.num < 0 {
+		// / rounds towards 0
+		q--
+	}
+	return q
+}
+
+// Ceil returns the smallest integer which isn't less than f
+func (f Frac) Ceil() int {
+	f = f.Simplify()
+	if f.num%f.Den() == 0 {
+		return f.num / f.Den()
+	}
+	return f.Floor() + 1
+}
+
+// Mod returns what's left of a after removing as many b as possible, like
+// the position in the bar: F(7, 2).Mod(N(3)) is 1/2. The result has the sign
+// of b (so it's never negative for a positive b, even if a is). Returns an
+// error if b is 0
+func (a Frac) Mod(b Frac) (Frac, error) {
+	if b.num == 0 {
+		return Frac{}, ErrZeroDivision
+	}
+	x, y := a.Rat(), b.Rat()
+	// floor(a / b), the quotient can be bigger than an int
+	q := new(big.Rat).Quo(x, y)
+	floor := new(big.Int).Div(q.Num(), q.Denom())
+	r := new(big.Rat).Mul(y, new(big.Rat).SetInt(floor))
+	return FromRat(r.Sub(x, r))
 }
 
 // I just realised that we don't need this function... == works on struct with
@@ -111,9 +238,18 @@ func (a Frac) Equal(b Frac) bool {
 	return a.Num() == b.Num() && a.Den() == b.Den()
 }
 
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// gcd is always positive (% keeps the sign of a, so minInt can give negative
+// remainders)
 func gcd(a, b int) int {
 	if b == 0 {
-		return a
+		return abs(a)
 	}
 	return gcd(b, a%b)
 }
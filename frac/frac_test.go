@@ -0,0 +1,232 @@
+package frac
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSimplify(t *testing.T) {
+	var rows = []struct {
+		f        Frac
+		expected Frac
+	}{
+		{raw(2, 4), raw(1, 2)},
+		{raw(1, -2), raw(-1, 2)},
+		{raw(-1, -2), raw(1, 2)},
+		{raw(-6, 4), raw(-3, 2)},
+		{raw(0, -5), raw(0, 1)},
+		{Frac{}, raw(0, 1)},
+	}
+	for _, row := range rows {
+		if actual := row.f.Simplify(); actual != row.expected {
+			t.Errorf("%v, actual: %v, expected: %v", row.f, actual, row.expected)
+		}
+	}
+	if F(1, -2) != F(-1, 2) {
+		t.Errorf("F(1, -2), actual: %v, expected: %v", F(1, -2), F(-1, 2))
+	}
+	// the zero value doesn't need to be simplified
+	var zero Frac
+	if zero != N(0) || zero != F(0, 3) || zero.Den() != 1 {
+		t.Errorf("zero value, actual: %d/%d, expected: %v", zero.Num(), zero.Den(), N(0))
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	if actual := F(1, 2).Add(F(1, 3)); actual != F(5, 6) {
+		t.Errorf("1/2 + 1/3, actual: %v, expected: %v", actual, F(5, 6))
+	}
+	if actual := F(1, 2).Minus(F(3, 4)); actual != F(-1, 4) {
+		t.Errorf("1/2 - 3/4, actual: %v, expected: %v", actual, F(-1, 4))
+	}
+	if actual := F(2, 3).Multiply(F(-3, 4)); actual != F(-1, 2) {
+		t.Errorf("2/3 * -3/4, actual: %v, expected: %v", actual, F(-1, 2))
+	}
+
+	actual, err := F(1, 2).Divide(F(-3, 4))
+	if err != nil || actual != F(-2, 3) {
+		t.Errorf("1/2 / -3/4, actual: %v (%v), expected: %v", actual, err, F(-2, 3))
+	}
+	if _, err := F(1, 2).Divide(N(0)); !errors.Is(err, ErrZeroDivision) {
+		t.Errorf("1/2 / 0, actual: %v, expected: %v", err, ErrZeroDivision)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	var rows = []struct {
+		a, b     Frac
+		expected int
+	}{
+		{F(1, 2), F(1, 3), 1},
+		{F(1, 3), F(1, 2), -1},
+		{F(2, 4), F(1, 2), 0},
+		{F(-1, 2), F(1, -3), -1},
+		{raw(1, -2), raw(-1, 2), 0},
+		{N(0), Frac{}, 0},
+		{F(-7, 2), N(-3), -1},
+	}
+	for _, row := range rows {
+		if actual := row.a.Compare(row.b); actual != row.expected {
+			t.Errorf("compare %v %v, actual: %d, expected: %d", row.a, row.b, actual, row.expected)
+		}
+		if actual := row.a.Less(row.b); actual != (row.expected < 0) {
+			t.Errorf("%v < %v, actual: %t, expected: %t", row.a, row.b, actual, row.expected < 0)
+		}
+	}
+}
+
+func TestFloorCeil(t *testing.T) {
+	var rows = []struct {
+		f           Frac
+		floor, ceil int
+	}{
+		{F(7, 2), 3, 4},
+		{F(-7, 2), -4, -3},
+		{N(3), 3, 3},
+		{N(-3), -3, -3},
+		{F(1, 3), 0, 1},
+		{F(-1, 3), -1, 0},
+		{raw(7, -2), -4, -3},
+		{N(0), 0, 0},
+	}
+	for _, row := range rows {
+		if actual := row.f.Floor(); actual != row.floor {
+			t.Errorf("floor %v, actual: %d, expected: %d", row.f, actual, row.floor)
+		}
+		if actual := row.f.Ceil(); actual != row.ceil {
+			t.Errorf("ceil %v, actual: %d, expected: %d", row.f, actual, row.ceil)
+		}
+	}
+}
+
+func TestMod(t *testing.T) {
+	var rows = []struct {
+		a, b     Frac
+		expected Frac
+	}{
+		{F(7, 2), N(3), F(1, 2)},
+		{N(6), N(3), N(0)},
+		{F(-1, 2), N(3), F(5, 2)},
+		{F(13, 4), F(3, 4), F(1, 4)},
+		{F(7, 2), N(-3), F(-5, 2)},
+	}
+	for _, row := range rows {
+		actual, err := row.a.Mod(row.b)
+		if err != nil || actual != row.expected {
+			t.Errorf("%v mod %v, actual: %v (%v), expected: %v", row.a, row.b, actual, err, row.expected)
+		}
+	}
+	if _, err := N(1).Mod(N(0)); !errors.Is(err, ErrZeroDivision) {
+		t.Errorf("1 mod 0, actual: %v, expected: %v", err, ErrZeroDivision)
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	big := F(1, maxInt)
+	if _, err := big.CheckedMultiply(F(1, 2)); !errors.Is(err, ErrOverflow) {
+		t.Errorf("1/max * 1/2, actual: %v, expected: %v", err, ErrOverflow)
+	}
+	if _, err := big.CheckedAdd(F(1, 2)); !errors.Is(err, ErrOverflow) {
+		t.Errorf("1/max + 1/2, actual: %v, expected: %v", err, ErrOverflow)
+	}
+	if _, err := N(maxInt).CheckedMinus(N(-1)); !errors.Is(err, ErrOverflow) {
+		t.Errorf("max - -1, actual: %v, expected: %v", err, ErrOverflow)
+	}
+	if _, err := N(0).CheckedMinus(N(minInt)); !errors.Is(err, ErrOverflow) {
+		t.Errorf("0 - min, actual: %v, expected: %v", err, ErrOverflow)
+	}
+	if _, err := NewFrac(minInt, -1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("min / -1, actual: %v, expected: %v", err, ErrOverflow)
+	}
+	var f Frac
+	if err := json.Unmarshal([]byte(fmt.Sprintf("[%d, -1]", minInt)), &f); !errors.Is(err, ErrOverflow) {
+		t.Errorf("json min / -1, actual: %v, expected: %v", err, ErrOverflow)
+	}
+	if f, err := NewFrac(minInt, -2); err != nil || f != N(-(minInt/2)) {
+		t.Errorf("min / -2, actual: %v (%v), expected: %v", f, err, N(-(minInt / 2)))
+	}
+	if _, err := N(maxInt).Divide(F(1, 2)); !errors.Is(err, ErrOverflow) {
+		t.Errorf("max / 1/2, actual: %v, expected: %v", err, ErrOverflow)
+	}
+
+	// the intermediate results overflow, but not the result
+	actual, err := big.CheckedMultiply(N(maxInt))
+	if err != nil || actual != N(1) {
+		t.Errorf("1/max * max, actual: %v (%v), expected: %v", actual, err, N(1))
+	}
+	third := F(1, maxInt/3*3)
+	actual, err = third.CheckedAdd(third)
+	if err != nil || actual != F(2, maxInt/3*3) {
+		t.Errorf("1/n + 1/n, actual: %v (%v), expected: %v", actual, err, F(2, maxInt/3*3))
+	}
+	actual, err = N(-1).CheckedMinus(N(minInt))
+	if err != nil || actual != N(maxInt) {
+		t.Errorf("-1 - min, actual: %v (%v), expected: %v", actual, err, N(maxInt))
+	}
+	if N(maxInt).Compare(N(maxInt-1)) != 1 || F(1, maxInt).Compare(F(1, maxInt-1)) != -1 {
+		t.Errorf("compare near max, actual: %d %d, expected: 1 -1",
+			N(maxInt).Compare(N(maxInt-1)), F(1, maxInt).Compare(F(1, maxInt-1)))
+	}
+	if m, err := F(1, maxInt).Mod(F(1, maxInt-1)); err != nil || m != F(1, maxInt) {
+		t.Errorf("mod near max, actual: %v (%v), expected: %v", m, err, F(1, maxInt))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("1/max * 1/2 didn't panic")
+		}
+	}()
+	big.Multiply(F(1, 2))
+}
+
+func TestRat(t *testing.T) {
+	r := F(-3, 4).Rat()
+	if r.RatString() != "-3/4" {
+		t.Errorf("rat, actual: %s, expected: %s", r.RatString(), "-3/4")
+	}
+	f, err := FromRat(r)
+	if err != nil || f != F(-3, 4) {
+		t.Errorf("from rat, actual: %v (%v), expected: %v", f, err, F(-3, 4))
+	}
+	r.SetFrac64(1<<62, 3)
+	r.Mul(r, r)
+	if _, err := FromRat(r); !errors.Is(err, ErrOverflow) {
+		t.Errorf("huge rat, actual: %v, expected: %v", err, ErrOverflow)
+	}
+}
+
+func TestBig(t *testing.T) {
+	// 1/max + 1/2 doesn't fit in a Frac, but it does in a Big
+	sum := F(1, maxInt).Big().Add(F(1, 2).Big())
+	if _, err := sum.Frac(); !errors.Is(err, ErrOverflow) {
+		t.Errorf("1/max + 1/2 as a frac, actual: %v, expected: %v", err, ErrOverflow)
+	}
+	back := sum.Minus(F(1, 2).Big())
+	if f, err := back.Frac(); err != nil || f != F(1, maxInt) {
+		t.Errorf("1/max + 1/2 - 1/2, actual: %v (%v), expected: %v", f, err, F(1, maxInt))
+	}
+
+	product := N(maxInt).Big().Multiply(N(maxInt).Big())
+	quotient, err := product.Divide(N(maxInt).Big())
+	if err != nil || !quotient.Equal(N(maxInt).Big()) {
+		t.Errorf("max * max / max, actual: %v (%v), expected: %v", quotient, err, N(maxInt))
+	}
+	if product.Compare(N(maxInt).Big()) != 1 || N(-1).Big().Compare(Big{}) != -1 {
+		t.Errorf("compare, actual: %d %d, expected: 1 -1",
+			product.Compare(N(maxInt).Big()), N(-1).Big().Compare(Big{}))
+	}
+	if _, err := product.Divide(Big{}); !errors.Is(err, ErrZeroDivision) {
+		t.Errorf("divide by the zero value, actual: %v, expected: %v", err, ErrZeroDivision)
+	}
+
+	// the zero value is 0
+	var zero Big
+	if f, err := zero.Frac(); err != nil || f != N(0) || zero.String() != "0/1" {
+		t.Errorf("zero value, actual: %v %s (%v), expected: %v", f, zero, err, N(0))
+	}
+	if actual := F(3, 4).Big().Float(); actual != 0.75 {
+		t.Errorf("float, actual: %f, expected: %f", actual, 0.75)
+	}
+}
@@ -0,0 +1,140 @@
+package frac
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var ErrParsing = errors.New("parsing fraction")
+
+// Parse reads a fraction written like 3/4, a mixed number like 1 1/2, an
+// integer like 2 or a decimal like 0.375 (the decimals have to be finite, so
+// there's no way to write 1/3 like that). All of them can be negative: -1 1/2
+// is -3/2. Returns ErrZeroDivision for a denominator of 0 (like 3/0), and
+// ErrOverflow if it doesn't fit in a Frac.
+func Parse(s string) (Frac, error) {
+	r, err := parse(strings.TrimSpace(s))
+	if errors.Is(err, ErrZeroDivision) {
+		return Frac{}, fmt.Errorf("%q: %w", s, err)
+	} else if err != nil {
+		return Frac{}, fmt.Errorf("%q: %s: %w", s, err, ErrParsing)
+	}
+	f, err := FromRat(r)
+	if err != nil {
+		return Frac{}, fmt.Errorf("%q: %w", s, err)
+	}
+	return f, nil
+}
+
+func parse(s string) (*big.Rat, error) {
+	negative := strings.HasPrefix(s, "-")
+	if negative || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+
+	var r *big.Rat
+	var err error
+	if fields := strings.Fields(s); len(fields) == 2 {
+		// mixed number, the whole part and then a fraction
+		whole, ok := new(big.Int).SetString(fields[0], 10)
+		if !ok || !digits(fields[0]) {
+			return nil, fmt.Errorf("invalid whole part %q", fields[0])
+		}
+		if !strings.Contains(fields[1], "/") {
+			return nil, fmt.Errorf("expected a fraction after %q", fields[0])
+		}
+		if r, err = parseFraction(fields[1]); err != nil {
+			return nil, err
+		}
+		r.Add(r, new(big.Rat).SetInt(whole))
+	} else if len(fields) != 1 {
+		return nil, errors.New("expected a number")
+	} else if strings.Contains(s, "/") {
+		r, err = parseFraction(s)
+	} else if strings.Contains(s, ".") {
+		r, err = parseDecimal(s)
+	} else {
+		n, ok := new(big.Int).SetString(s, 10)
+		if !ok || !digits(s) {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		r = new(big.Rat).SetInt(n)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if negative {
+		r.Neg(r)
+	}
+	return r, nil
+}
+
+// parseFraction reads num/den, both positive integers
+func parseFraction(s string) (*big.Rat, error) {
+	i := strings.IndexByte(s, '/')
+	num, den := s[:i], s[i+1:]
+	n, ok := new(big.Int).SetString(num, 10)
+	if !ok || !digits(num) {
+		return nil, fmt.Errorf("invalid numerator %q", num)
+	}
+	d, ok := new(big.Int).SetString(den, 10)
+	if !ok || !digits(den) {
+		return nil, fmt.Errorf("invalid denominator %q", den)
+	}
+	if d.Sign() == 0 {
+		return nil, ErrZeroDivision
+	}
+	return new(big.Rat).SetFrac(n, d), nil
+}
+
+// parseDecimal reads a number with a dot, like 0.375, .5 or 2.
+func parseDecimal(s string) (*big.Rat, error) {
+	i := strings.IndexByte(s, '.')
+	whole, decimals := s[:i], s[i+1:]
+	if whole == "" && decimals == "" {
+		return nil, errors.New("expected digits around the dot")
+	}
+	if (whole != "" && !digits(whole)) || (decimals != "" && !digits(decimals)) {
+		return nil, fmt.Errorf("invalid decimal %q", s)
+	}
+	n, _ := new(big.Int).SetString(whole+decimals, 10)
+	den := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(len(decimals))), nil)
+	return new(big.Rat).SetFrac(n, den), nil
+}
+
+// digits returns true if s is only made of digits (big.Int.SetString accepts
+// underscores and signs)
+func digits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalText writes the fraction like String (3/4). JSON still uses an
+// array, see MarshalJSON
+func (f Frac) MarshalText() ([]byte, error) {
+	return []byte(f.Simplify().String()), nil
+}
+
+// UnmarshalText accepts everything Parse does
+func (f *Frac) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
+
+// Set makes *Frac a flag.Value, so it can be used with flag.Var
+func (f *Frac) Set(s string) error {
+	return f.UnmarshalText([]byte(s))
+}
@@ -0,0 +1,128 @@
+package frac
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	var rows = []struct {
+		s        string
+		expected Frac
+	}{
+		{"3/4", F(3, 4)},
+		{"6/8", F(3, 4)},
+		{"-3/4", F(-3, 4)},
+		{"+3/4", F(3, 4)},
+		{"1 1/2", F(3, 2)},
+		{"-1 1/2", F(-3, 2)},
+		{"2   3/4", F(11, 4)},
+		{"2", N(2)},
+		{"-7", N(-7)},
+		{"0", N(0)},
+		{"0.375", F(3, 8)},
+		{".5", F(1, 2)},
+		{"2.", N(2)},
+		{"-1.25", F(-5, 4)},
+		{" 3/4 ", F(3, 4)},
+	}
+	for _, row := range rows {
+		actual, err := Parse(row.s)
+		if err != nil || actual != row.expected {
+			t.Errorf("%q, actual: %v (%v), expected: %v", row.s, actual, err, row.expected)
+		}
+	}
+
+	for _, s := range []string{"", "-", "/", "3/", "/4", "3/-4", "1/2/3", "1 2", "1 1/2 1", "a", ".", "1.2.3",
+		"1_000", "0x10", "1e3", "1 -1/2", "- 1/2", "1.5/2"} {
+		if _, err := Parse(s); !errors.Is(err, ErrParsing) {
+			t.Errorf("%q, actual: %v, expected: %v", s, err, ErrParsing)
+		}
+	}
+	for _, s := range []string{"3/0", "-3/0", "1 1/0", "0/0"} {
+		if _, err := Parse(s); !errors.Is(err, ErrZeroDivision) {
+			t.Errorf("%q, actual: %v, expected: %v", s, err, ErrZeroDivision)
+		}
+	}
+	if _, err := Parse("99999999999999999999"); !errors.Is(err, ErrOverflow) {
+		t.Errorf("huge, actual: %v, expected: %v", err, ErrOverflow)
+	}
+}
+
+func TestText(t *testing.T) {
+	text, err := F(-6, 8).MarshalText()
+	if err != nil || string(text) != "-3/4" {
+		t.Errorf("marshal, actual: %q (%v), expected: %q", text, err, "-3/4")
+	}
+	var f Frac
+	if err := f.UnmarshalText([]byte("1 1/2")); err != nil || f != F(3, 2) {
+		t.Errorf("unmarshal, actual: %v (%v), expected: %v", f, err, F(3, 2))
+	}
+
+	// JSON still uses arrays
+	data, err := json.Marshal(F(3, 4))
+	if err != nil || string(data) != "[3,4]" {
+		t.Errorf("json, actual: %s (%v), expected: %s", data, err, "[3,4]")
+	}
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	duration := N(1)
+	flags.Var(&duration, "duration", "how long")
+	if err := flags.Parse([]string{"-duration", "1 1/2"}); err != nil || duration != F(3, 2) {
+		t.Errorf("flag, actual: %v (%v), expected: %v", duration, err, F(3, 2))
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var rows = []struct {
+		data     string
+		expected Frac
+	}{
+		{`[3, 4]`, F(3, 4)},
+		{`[6, 8]`, F(3, 4)},
+		{`[1, -2]`, F(-1, 2)},
+		{`"3/4"`, F(3, 4)},
+		{`"1 1/2"`, F(3, 2)},
+		{`"0.25"`, F(1, 4)},
+		{`2`, N(2)},
+		{`-3`, N(-3)},
+		{` [1,2] `, F(1, 2)},
+	}
+	for _, row := range rows {
+		var f Frac
+		if err := json.Unmarshal([]byte(row.data), &f); err != nil || f != row.expected {
+			t.Errorf("%s, actual: %v (%v), expected: %v", row.data, f, err, row.expected)
+		}
+	}
+
+	for _, data := range []string{`[]`, `[3]`, `[1, 2, 3]`, `[1.5, 2]`, `["1", 2]`, `"3/"`, `1.5`, `true`, `{}`} {
+		var f Frac
+		if err := json.Unmarshal([]byte(data), &f); !errors.Is(err, ErrParsing) {
+			t.Errorf("%s, actual: %v, expected: %v", data, err, ErrParsing)
+		}
+	}
+	var f Frac
+	// the same whether it's an array or a string
+	for _, data := range []string{`[3, 0]`, `"3/0"`} {
+		if err := json.Unmarshal([]byte(data), &f); !errors.Is(err, ErrZeroDivision) {
+			t.Errorf("%s, actual: %v, expected: %v", data, err, ErrZeroDivision)
+		}
+	}
+
+	// null leaves it alone
+	f = F(1, 2)
+	if err := json.Unmarshal([]byte(`null`), &f); err != nil || f != F(1, 2) {
+		t.Errorf("null, actual: %v (%v), expected: %v", f, err, F(1, 2))
+	}
+
+	var zero Frac
+	data, err := json.Marshal(zero)
+	if err != nil || string(data) != "[0,1]" {
+		t.Errorf("zero value, actual: %s (%v), expected: %s", data, err, "[0,1]")
+	}
+	if err := json.Unmarshal(data, &f); err != nil || f != N(0) {
+		t.Errorf("zero value back, actual: %v (%v), expected: %v", f, err, N(0))
+	}
+}
@@ -0,0 +1,152 @@
+package labels
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrParsingChord = errors.New("parsing chord")
+
+// qualities are the intervals from the root for every kind of chord. Some
+// have several spellings
+var qualities = map[string]string{
+	"":        "P1 M3 P5",
+	"m":       "P1 m3 P5",
+	"min":     "P1 m3 P5",
+	"-":       "P1 m3 P5",
+	"dim":     "P1 m3 d5",
+	"°":       "P1 m3 d5",
+	"aug":     "P1 M3 A5",
+	"+":       "P1 M3 A5",
+	"sus2":    "P1 M2 P5",
+	"sus4":    "P1 P4 P5",
+	"sus":     "P1 P4 P5",
+	"5":       "P1 P5",
+	"6":       "P1 M3 P5 M6",
+	"m6":      "P1 m3 P5 M6",
+	"7":       "P1 M3 P5 m7",
+	"maj7":    "P1 M3 P5 M7",
+	"M7":      "P1 M3 P5 M7",
+	"Δ7":      "P1 M3 P5 M7",
+	"Δ":       "P1 M3 P5 M7",
+	"m7":      "P1 m3 P5 m7",
+	"min7":    "P1 m3 P5 m7",
+	"-7":      "P1 m3 P5 m7",
+	"mMaj7":   "P1 m3 P5 M7",
+	"m(maj7)": "P1 m3 P5 M7",
+	"m7b5":    "P1 m3 d5 m7",
+	"ø":       "P1 m3 d5 m7",
+	"ø7":      "P1 m3 d5 m7",
+	"dim7":    "P1 m3 d5 d7",
+	"°7":      "P1 m3 d5 d7",
+	"aug7":    "P1 M3 A5 m7",
+	"+7":      "P1 M3 A5 m7",
+	"7#5":     "P1 M3 A5 m7",
+	"7sus4":   "P1 P4 P5 m7",
+	"add9":    "P1 M3 P5 M9",
+	"9":       "P1 M3 P5 m7 M9",
+	"maj9":    "P1 M3 P5 M7 M9",
+	"m9":      "P1 m3 P5 m7 M9",
+	"11":      "P1 M3 P5 m7 M9 P11",
+	"13":      "P1 M3 P5 m7 M9 M13",
+}
+
+// DefaultChordOctave is the octave of the root for Chord
+const DefaultChordOctave = 4
+
+// Chord returns the notes of the chord symbol, from the lowest to the
+// highest, with the root in DefaultChordOctave. See ChordAt.
+func Chord(symbol string) ([]Pitch, error) {
+	return ChordAt(symbol, DefaultChordOctave)
+}
+
+// ChordAt returns the notes of the chord symbol (like C, F#m, Bb7, Am7/G),
+// from the lowest to the highest, with the root in the given octave.
+//
+// The note after a slash is the bass. If it's in the chord, it's an
+// inversion: the notes below the bass move up an octave (C/E is E G C, and
+// C9/E is E G Bb C D). A ninth or above in the bass comes down to the root's
+// octave first (C9/D is D E G Bb C). If it isn't in the chord, the bass is
+// added below the root (C/D is D C E G).
+func ChordAt(symbol string, octave int) ([]Pitch, error) {
+	root, pos, err := parseChordNote(symbol, 0)
+	if err != nil {
+		return nil, err
+	}
+	root.Octave = octave
+
+	end := strings.IndexByte(symbol[pos:], '/')
+	if end == -1 {
+		end = len(symbol)
+	} else {
+		end += pos
+	}
+	d, ok := qualities[symbol[pos:end]]
+	if !ok {
+		return nil, chordError(symbol, pos, fmt.Sprintf("unknown quality %q", symbol[pos:end]))
+	}
+	var pitches []Pitch
+	for _, i := range intervals(d) {
+		pitches = append(pitches, root.Transpose(i))
+	}
+	if end == len(symbol) {
+		return pitches, nil
+	}
+
+	bass, pos, err := parseChordNote(symbol, end+1)
+	if err != nil {
+		return nil, err
+	}
+	if pos != len(symbol) {
+		return nil, chordError(symbol, pos, "expected the end after the bass")
+	}
+	for i, p := range pitches {
+		if mod(p.Index()-bass.Index(), 12) != 0 {
+			continue
+		}
+		// inversion. If the bass is an extension (the ninth of C9/D), it
+		// comes down to the root's octave first. Then everything below it
+		// goes up, and the chord gets sorted again (the ninth of C9/E is now
+		// above the raised root)
+		bass = pitches[i]
+		for bass.Index()-root.Index() >= 12 {
+			bass.Octave--
+		}
+		pitches[i] = bass
+		for j := range pitches {
+			for pitches[j].Index() < bass.Index() {
+				pitches[j].Octave++
+			}
+		}
+		sort.SliceStable(pitches, func(a, b int) bool {
+			return pitches[a].Index() < pitches[b].Index()
+		})
+		return pitches, nil
+	}
+	// the highest octave which is still below the root
+	bass.Octave = root.Octave
+	if bass.Index() >= root.Index() {
+		bass.Octave--
+	}
+	return append([]Pitch{bass}, pitches...), nil
+}
+
+// parseChordNote reads a letter (upper case only, since m means minor) and
+// its accidentals. The octave is left at 0
+func parseChordNote(symbol string, pos int) (p Pitch, end int, err error) {
+	if pos >= len(symbol) || symbol[pos] < 'A' || symbol[pos] > 'G' {
+		return p, pos, chordError(symbol, pos, "expected a letter from A to G")
+	}
+	p.Letter, _ = parseLetter(symbol[pos])
+	p.Accidental, end, err = parseAccidentals(symbol, pos+1)
+	if err != nil {
+		return p, end, chordError(symbol, end, "invalid accidentals")
+	}
+	return p, end, nil
+}
+
+func chordError(symbol string, pos int, msg string) error {
+	return fmt.Errorf("%q at byte %d: %s: %w", symbol, pos, msg, ErrParsingChord)
+}
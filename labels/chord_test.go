@@ -0,0 +1,112 @@
+package labels
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestScale(t *testing.T) {
+	var rows = []struct {
+		root     string
+		mode     Mode
+		expected string
+	}{
+		{"C4", ModeMajor, "C4 D4 E4 F4 G4 A4 B4"},
+		{"F4", "", "F4 G4 A4 Bb4 C5 D5 E5"},
+		{"D4", ModeMinor, "D4 E4 F4 G4 A4 Bb4 C5"},
+		{"A3", ModeHarmonicMinor, "A3 B3 C4 D4 E4 F4 G#4"},
+		{"A3", ModeMelodicMinor, "A3 B3 C4 D4 E4 F#4 G#4"},
+		{"F#4", ModeMajor, "F#4 G#4 A#4 B4 C#5 D#5 E#5"},
+		{"Gb4", ModeMajor, "Gb4 Ab4 Bb4 Cb5 Db5 Eb5 F5"},
+		{"D4", ModeDorian, "D4 E4 F4 G4 A4 B4 C5"},
+		{"E4", ModePhrygian, "E4 F4 G4 A4 B4 C5 D5"},
+		{"F4", ModeLydian, "F4 G4 A4 B4 C5 D5 E5"},
+		{"G4", ModeMixolydian, "G4 A4 B4 C5 D5 E5 F5"},
+		{"B3", ModeLocrian, "B3 C4 D4 E4 F4 G4 A4"},
+		{"C4", ModeMajorPentatonic, "C4 D4 E4 G4 A4"},
+		{"A4", ModeMinorPentatonic, "A4 C5 D5 E5 G5"},
+		{"A4", ModeBlues, "A4 C5 D5 Eb5 E5 G5"},
+	}
+	labels := NewLabels(nil)
+	for _, row := range rows {
+		root, err := labels.Pitch(row.root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pitches, err := Scale(root, row.mode)
+		if err != nil {
+			t.Errorf("%s %s, expected: pass, got err: %s", row.root, row.mode, err)
+			continue
+		}
+		if actual := strings.Join(labels.Names(pitches), " "); actual != row.expected {
+			t.Errorf("%s %s, actual: %s, expected: %s", row.root, row.mode, actual, row.expected)
+		}
+	}
+
+	if _, err := Scale(Pitch{}, "bebop"); !errors.Is(err, ErrUnknownMode) {
+		t.Errorf("bebop, actual: %v, expected: %v", err, ErrUnknownMode)
+	}
+}
+
+func TestChord(t *testing.T) {
+	var rows = []struct {
+		symbol   string
+		expected string
+	}{
+		{"C", "C4 E4 G4"},
+		{"Am", "A4 C5 E5"},
+		{"F#m", "F#4 A4 C#5"},
+		{"Bb7", "Bb4 D5 F5 Ab5"},
+		{"Ebmaj7", "Eb4 G4 Bb4 D5"},
+		{"Bdim", "B4 D5 F5"},
+		{"Bdim7", "B4 D5 F5 Ab5"},
+		{"Bø", "B4 D5 F5 A5"},
+		{"Caug", "C4 E4 G#4"},
+		{"Dsus4", "D4 G4 A4"},
+		{"G9", "G4 B4 D5 F5 A5"},
+		{"C5", "C4 G4"},
+		{"C/E", "E4 G4 C5"},
+		{"C/G", "G4 C5 E5"},
+		{"Am7/G", "G5 A5 C6 E6"},
+		{"C/D", "D3 C4 E4 G4"},
+		{"C/Bb", "Bb3 C4 E4 G4"},
+		{"Dm/C", "C4 D4 F4 A4"},
+		{"C9/E", "E4 G4 Bb4 C5 D5"},
+		{"C9/D", "D4 E4 G4 Bb4 C5"},
+		{"C13/G", "G4 Bb4 C5 D5 E5 A5"},
+		{"C11/F", "F4 G4 Bb4 C5 D5 E5"},
+	}
+	labels := NewLabels(nil)
+	for _, row := range rows {
+		pitches, err := Chord(row.symbol)
+		if err != nil {
+			t.Errorf("%q, expected: pass, got err: %s", row.symbol, err)
+			continue
+		}
+		if actual := strings.Join(labels.Names(pitches), " "); actual != row.expected {
+			t.Errorf("%q, actual: %s, expected: %s", row.symbol, actual, row.expected)
+		}
+	}
+
+	pitches, err := ChordAt("A", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	freqs := labels.Frequencies(pitches)
+	expected := []float64{220, labels.F("C#4"), labels.F("E4")}
+	for i := range expected {
+		if freqs[i] != expected[i] {
+			t.Errorf("A3 chord, actual: %v, expected: %v", freqs, expected)
+			break
+		}
+	}
+}
+
+func TestChordErrors(t *testing.T) {
+	for _, symbol := range []string{"", "H", "am", "Cmaj8", "C/", "C/H", "C/E7", "C#b"} {
+		if _, err := Chord(symbol); !errors.Is(err, ErrParsingChord) {
+			t.Errorf("%q, actual: %v, expected: %v", symbol, err, ErrParsingChord)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package labels
+
+import (
+	"sync"
+	"testing"
+)
+
+// these tests are meant to be run with -race
+
+func TestLabelsParallel(t *testing.T) {
+	labels := NewLabels(nil)
+	names := []string{"A4", "C#5", "Bb3", "G2", "E6", "F#1", "D4", "Ab5"}
+
+	t.Run("group", func(t *testing.T) {
+		for g := 0; g < 8; g++ {
+			g := g
+			t.Run("reader", func(t *testing.T) {
+				t.Parallel()
+				for i := 0; i < 200; i++ {
+					name := names[(g+i)%len(names)]
+					freq, err := labels.Frequency(name)
+					if err != nil {
+						t.Fatal(err)
+					}
+					label, _, _, err := labels.Label(freq, Sharps)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if _, err := labels.Frequency(label); err != nil {
+						t.Fatal(err)
+					}
+					labels.FromIndex(i % 88)
+					if _, err := labels.MIDI(name); err != nil {
+						t.Fatal(err)
+					}
+				}
+			})
+		}
+	})
+}
+
+func TestLabelsParallelSetters(t *testing.T) {
+	labels := NewLabels(nil)
+	werckmeister := WerckmeisterIII(440)
+
+	var wg sync.WaitGroup
+	// one goroutine keeps switching the tuning and the naming, while the
+	// others read
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			if i%2 == 0 {
+				labels.SetTuning(werckmeister)
+				labels.SetNaming(Solfege)
+			} else {
+				labels.SetTuning(nil)
+				labels.SetNaming(nil)
+			}
+		}
+	}()
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				// A4 is always 440 in both tunings, and "A4" isn't a name in
+				// solfège
+				if freq, err := labels.Frequency("A4"); err == nil && freq != 440 {
+					t.Errorf("A4, actual: %f, expected: %f", freq, 440.0)
+				}
+				if freq, err := labels.Frequency("La4"); err == nil && freq != 440 {
+					t.Errorf("La4, actual: %f, expected: %f", freq, 440.0)
+				}
+				if _, _, _, err := labels.Label(261.63, Flats); err != nil {
+					t.Error(err)
+				}
+				labels.Names([]Pitch{{Letter: 5, Octave: 4}})
+				labels.Frequencies([]Pitch{{Letter: 5, Octave: 4}})
+			}
+		}()
+	}
+	wg.Wait()
+}
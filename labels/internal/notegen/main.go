@@ -0,0 +1,96 @@
+// Command notegen writes the Note constants of package labels (see
+// labels/note.go). It's run by go generate, from the labels directory:
+//
+//	go run ./internal/notegen -o notes_gen.go
+//
+// It doesn't import labels, so that it still works when the generated file is
+// broken.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"math"
+	"strconv"
+)
+
+// names are the names of the 12 tones, the sharps first and then the flats
+// (for the black keys)
+var names = [12][]string{
+	{"C"}, {"Cs", "Db"}, {"D"}, {"Ds", "Eb"}, {"E"}, {"F"},
+	{"Fs", "Gb"}, {"G"}, {"Gs", "Ab"}, {"A"}, {"As", "Bb"}, {"B"},
+}
+
+// labels are the same as in scientific pitch notation (with sharps)
+var labels = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+func main() {
+	output := flag.String("o", "notes_gen.go", "where to write the code")
+	flag.Parse()
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by notegen; DO NOT EDIT.\n\n")
+	buf.WriteString("package labels\n\n")
+
+	buf.WriteString("// every MIDI note, with sharps and flats for the black keys\n")
+	buf.WriteString("const (\n")
+	for key := 0; key <= 127; key++ {
+		for i, name := range names[key%12] {
+			if i > 0 {
+				// a flat is the same number as the sharp, it can't have its
+				// own label
+				fmt.Fprintf(&buf, "// %s%s is %s%s, so its Label is %q\n", name, octave(key),
+					names[key%12][0], octave(key), label(key))
+			}
+			fmt.Fprintf(&buf, "%s%s Note = %d\n", name, octave(key), key)
+		}
+	}
+	buf.WriteString(")\n\n")
+
+	buf.WriteString("// noteFrequencies are in 12-TET, with A4 at 440 Hz\n")
+	buf.WriteString("var noteFrequencies = [128]float64{\n")
+	for key := 0; key <= 127; key++ {
+		fmt.Fprintf(&buf, "%s,\n", strconv.FormatFloat(frequency(key), 'g', -1, 64))
+	}
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("var noteLabels = [128]string{\n")
+	for key := 0; key <= 127; key++ {
+		fmt.Fprintf(&buf, "%q,\n", label(key))
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("formatting: %s", err)
+	}
+	if err := ioutil.WriteFile(*output, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// octave writes the octave so that it can go in an identifier (C-1 is CNeg1)
+func octave(key int) string {
+	o := key/12 - 1
+	if o < 0 {
+		return fmt.Sprintf("Neg%d", -o)
+	}
+	return strconv.Itoa(o)
+}
+
+// label is the name in scientific pitch notation (with sharps)
+func label(key int) string {
+	return fmt.Sprintf("%s%d", labels[key%12], key/12-1)
+}
+
+// frequency is computed the same way as labels.Standard, in cents from C0
+func frequency(key int) float64 {
+	cents := func(key int) float64 {
+		return 1200*float64(key/12-1) + 100*float64(key%12)
+	}
+	return 440 * math.Pow(2, (cents(key)-cents(69))/1200)
+}
@@ -0,0 +1,197 @@
+package labels
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrParsingInterval = errors.New("parsing interval")
+
+// Interval is the distance between two pitches. It keeps track of the number
+// of letters as well as the number of semitones, so that a minor third above
+// A is C (and not B#, which is the same key but an augmented second).
+//
+// Intervals going down are negative (see Between and Negate).
+type Interval struct {
+	// steps is the number of letters: 0 for a unison, 2 for a third, 7 for an
+	// octave
+	steps     int
+	semitones int
+}
+
+// ParseInterval reads names like P5, m3, M9 (compound intervals are fine),
+// A4, d5 or AA4 (doubly augmented). The quality goes first: P (perfect), M
+// (major), m (minor), A (augmented), d (diminished). A leading - makes it go
+// down.
+func ParseInterval(name string) (Interval, error) {
+	s := name
+	down := strings.HasPrefix(s, "-")
+	if down {
+		s = s[1:]
+	}
+	end := 0
+	for end < len(s) && (s[end] < '0' || s[end] > '9') {
+		end++
+	}
+	quality := s[:end]
+	number, err := strconv.Atoi(s[end:])
+	if err != nil || number < 1 {
+		return Interval{}, fmt.Errorf("%q: expected a number (1 for a unison): %w", name, ErrParsingInterval)
+	}
+
+	steps := number - 1
+	i := Interval{steps: steps, semitones: steps/7*12 + letterTones[steps%7]}
+	perfect := isPerfect(steps)
+	switch {
+	case quality == "P" && perfect:
+	case quality == "M" && !perfect:
+	case quality == "m" && !perfect:
+		i.semitones--
+	case quality != "" && strings.Count(quality, "A") == len(quality):
+		i.semitones += len(quality)
+	case quality != "" && strings.Count(quality, "d") == len(quality) && steps != 0:
+		i.semitones -= len(quality)
+		if !perfect {
+			i.semitones--
+		}
+	default:
+		return Interval{}, fmt.Errorf("%q: invalid quality %q for a %d: %w", name, quality, number, ErrParsingInterval)
+	}
+	if down {
+		i = i.Negate()
+	}
+	return i, nil
+}
+
+// MustParseInterval is like ParseInterval but panics if there's an error. Only
+// use it with static strings
+func MustParseInterval(name string) Interval {
+	i, err := ParseInterval(name)
+	if err != nil {
+		panic(err)
+	}
+	return i
+}
+
+// isPerfect returns true for unisons, fourths, fifths and octaves (which are
+// perfect, not major or minor)
+func isPerfect(steps int) bool {
+	s := mod(steps, 7)
+	return s == 0 || s == 3 || s == 4
+}
+
+// Semitones is negative for intervals going down
+func (i Interval) Semitones() int {
+	return i.semitones
+}
+
+// Number is 1 for a unison, 3 for a third, 9 for a ninth... (it's negative for
+// intervals going down)
+func (i Interval) Number() int {
+	if i.steps < 0 || (i.steps == 0 && i.semitones < 0) {
+		return i.steps - 1
+	}
+	return i.steps + 1
+}
+
+// Negate returns the same interval going the other way
+func (i Interval) Negate() Interval {
+	return Interval{steps: -i.steps, semitones: -i.semitones}
+}
+
+// Add returns the interval made of i and then j (M3 + m3 is P5)
+func (i Interval) Add(j Interval) Interval {
+	return Interval{steps: i.steps + j.steps, semitones: i.semitones + j.semitones}
+}
+
+func (i Interval) String() string {
+	sign := ""
+	if i.steps < 0 || (i.steps == 0 && i.semitones < 0) {
+		sign = "-"
+		i = i.Negate()
+	}
+	diff := i.semitones - (i.steps/7*12 + letterTones[i.steps%7])
+	var quality string
+	switch {
+	case isPerfect(i.steps) && diff == 0:
+		quality = "P"
+	case !isPerfect(i.steps) && diff == 0:
+		quality = "M"
+	case !isPerfect(i.steps) && diff == -1:
+		quality = "m"
+	case diff > 0:
+		quality = strings.Repeat("A", diff)
+	case isPerfect(i.steps):
+		quality = strings.Repeat("d", -diff)
+	default:
+		quality = strings.Repeat("d", -diff-1)
+	}
+	return fmt.Sprintf("%s%s%d", sign, quality, i.steps+1)
+}
+
+// Transpose returns the pitch i above p (or below if i is negative)
+func (p Pitch) Transpose(i Interval) Pitch {
+	letter := p.Letter + i.steps
+	q := Pitch{
+		Letter: mod(letter, 7),
+		Octave: p.Octave + (letter-mod(letter, 7))/7,
+	}
+	// q is a natural so far, the accidental makes up the difference
+	q.Accidental = p.Index() + i.semitones - q.Index()
+	return q
+}
+
+// TransposeDown returns the pitch i below p
+func (p Pitch) TransposeDown(i Interval) Pitch {
+	return p.Transpose(i.Negate())
+}
+
+// Between returns the interval from a to b (negative if b is below a)
+func Between(a, b Pitch) Interval {
+	return Interval{
+		steps:     b.Octave*7 + b.Letter - a.Octave*7 - a.Letter,
+		semitones: b.Index() - a.Index(),
+	}
+}
+
+// Transpose returns the name i above name (with the current naming)
+func (n *Labels) Transpose(name string, i Interval) (string, error) {
+	naming := n.Naming()
+	p, err := naming.Parse(name)
+	if err != nil {
+		return "", err
+	}
+	return naming.Format(p.Transpose(i)), nil
+}
+
+// Interval returns the interval from a to b (with the current naming)
+func (n *Labels) Interval(a, b string) (Interval, error) {
+	naming := n.Naming()
+	pa, err := naming.Parse(a)
+	if err != nil {
+		return Interval{}, err
+	}
+	pb, err := naming.Parse(b)
+	if err != nil {
+		return Interval{}, err
+	}
+	return Between(pa, pb), nil
+}
+
+// TransposeFrequency moves freq by the interval's semitones in the current
+// tuning: it goes to the frequency of the key that many semitones away from
+// the closest key, keeping how far freq is from the key. So in a
+// temperament, a fifth isn't always the same ratio. (The spelling doesn't
+// matter here, A4 and d5 are the same)
+func (n *Labels) TransposeFrequency(freq float64, i Interval) (float64, error) {
+	_, tuning := n.current()
+	if !(freq > 0) || math.IsInf(freq, 1) {
+		return 0, fmt.Errorf("%f: %w", freq, ErrInvalidFrequency)
+	}
+	index := n.nearest(tuning, freq)
+	ratio := freq / n.frequency(tuning, index)
+	return n.frequency(tuning, index+i.semitones) * ratio, nil
+}
@@ -0,0 +1,145 @@
+package labels
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseInterval(t *testing.T) {
+	var rows = []struct {
+		name      string
+		semitones int
+		number    int
+		// what String gives back (the same if empty)
+		str string
+	}{
+		{"P1", 0, 1, ""},
+		{"m2", 1, 2, ""},
+		{"M2", 2, 2, ""},
+		{"m3", 3, 3, ""},
+		{"M3", 4, 3, ""},
+		{"P4", 5, 4, ""},
+		{"A4", 6, 4, ""},
+		{"d5", 6, 5, ""},
+		{"P5", 7, 5, ""},
+		{"A5", 8, 5, ""},
+		{"m6", 8, 6, ""},
+		{"d7", 9, 7, ""},
+		{"m7", 10, 7, ""},
+		{"M7", 11, 7, ""},
+		{"P8", 12, 8, ""},
+		{"m9", 13, 9, ""},
+		{"M10", 16, 10, ""},
+		{"P11", 17, 11, ""},
+		{"M13", 21, 13, ""},
+		{"P15", 24, 15, ""},
+		{"AA4", 7, 4, ""},
+		{"dd3", 1, 3, ""},
+		{"A1", 1, 1, ""},
+		{"-P5", -7, -5, ""},
+		{"-m3", -3, -3, ""},
+		{"-P1", 0, 1, "P1"},
+	}
+	for _, row := range rows {
+		i, err := ParseInterval(row.name)
+		if err != nil {
+			t.Errorf("%q, expected: pass, got err: %s", row.name, err)
+			continue
+		}
+		if i.Semitones() != row.semitones {
+			t.Errorf("%q semitones, actual: %d, expected: %d", row.name, i.Semitones(), row.semitones)
+		}
+		if i.Number() != row.number {
+			t.Errorf("%q number, actual: %d, expected: %d", row.name, i.Number(), row.number)
+		}
+		str := row.str
+		if str == "" {
+			str = row.name
+		}
+		if i.String() != str {
+			t.Errorf("%q string, actual: %q, expected: %q", row.name, i.String(), str)
+		}
+	}
+
+	for _, name := range []string{"", "P", "5", "P3", "M5", "m4", "d1", "x3", "P0", "Ad5", "--P5"} {
+		if _, err := ParseInterval(name); !errors.Is(err, ErrParsingInterval) {
+			t.Errorf("%q, actual: %v, expected: %v", name, err, ErrParsingInterval)
+		}
+	}
+
+	if sum := MustParseInterval("M3").Add(MustParseInterval("m3")); sum != MustParseInterval("P5") {
+		t.Errorf("M3 + m3, actual: %s, expected: P5", sum)
+	}
+}
+
+func TestTranspose(t *testing.T) {
+	labels := NewLabels(nil)
+	var rows = []struct {
+		from     string
+		interval string
+		to       string
+	}{
+		{"C4", "M3", "E4"},
+		{"A4", "m3", "C5"},
+		{"A4", "A2", "B#4"},
+		{"B3", "m2", "C4"},
+		{"F4", "A4", "B4"},
+		{"B4", "d5", "F5"},
+		{"Eb4", "M6", "C5"},
+		{"C4", "M9", "D5"},
+		{"C4", "-P5", "F3"},
+		{"E4", "-m3", "C#4"},
+		{"C4", "-m2", "B3"},
+		{"Db4", "d7", "Cbb5"},
+	}
+	for _, row := range rows {
+		i := MustParseInterval(row.interval)
+		actual, err := labels.Transpose(row.from, i)
+		if err != nil || actual != row.to {
+			t.Errorf("%s + %s, actual: %q (%v), expected: %q", row.from, row.interval, actual, err, row.to)
+		}
+		between, err := labels.Interval(row.from, row.to)
+		if err != nil || between != i {
+			t.Errorf("%s to %s, actual: %s (%v), expected: %s", row.from, row.to, between, err, i)
+		}
+		from, _ := labels.Pitch(row.from)
+		to, _ := labels.Pitch(row.to)
+		if back := to.TransposeDown(i); back != from {
+			t.Errorf("%s - %s, actual: %v, expected: %v", row.to, row.interval, back, from)
+		}
+	}
+
+	if _, err := labels.Transpose("H4", MustParseInterval("P5")); !errors.Is(err, ErrParsingName) {
+		t.Errorf("H4, actual: %v, expected: %v", err, ErrParsingName)
+	}
+}
+
+func TestTransposeFrequency(t *testing.T) {
+	labels := NewLabels(nil)
+	fifth := MustParseInterval("P5")
+	freq, err := labels.TransposeFrequency(440, fifth)
+	if err != nil || math.Abs(freq-labels.F("E5")) > 1e-9 {
+		t.Errorf("A4 + P5, actual: %f (%v), expected: %f", freq, err, labels.F("E5"))
+	}
+	// slightly out of tune notes stay as out of tune
+	freq, err = labels.TransposeFrequency(445, MustParseInterval("-P8"))
+	if err != nil || math.Abs(freq-222.5) > 1e-9 {
+		t.Errorf("445 - P8, actual: %f (%v), expected: %f", freq, err, 222.5)
+	}
+
+	// in meantone, C-G is a good fifth but G#-Eb is the wolf
+	labels.SetTuning(QuarterCommaMeantone(440, 0))
+	good, _ := labels.TransposeFrequency(labels.F("C4"), fifth)
+	wolf, _ := labels.TransposeFrequency(labels.F("G#4"), fifth)
+	if math.Abs(good-labels.F("G4")) > 1e-9 {
+		t.Errorf("C4 + P5, actual: %f, expected: %f", good, labels.F("G4"))
+	}
+	if math.Abs(good/labels.F("C4")-wolf/labels.F("G#4")) < 0.01 {
+		t.Errorf("the wolf fifth has the same ratio as C-G: %f", wolf/labels.F("G#4"))
+	}
+
+	if _, err := labels.TransposeFrequency(0, fifth); !errors.Is(err, ErrInvalidFrequency) {
+		t.Errorf("0 Hz, actual: %v, expected: %v", err, ErrInvalidFrequency)
+	}
+}
@@ -0,0 +1,129 @@
+package labels
+
+import "fmt"
+
+// Key is a tonic and a mode, like F major or D dorian. It spells notes the way
+// they would be written in that key (it's a Speller)
+type Key struct {
+	// Tonic's octave is ignored
+	Tonic Pitch
+	Mode  Mode
+}
+
+// keyModes are the modes which have a key signature. fifths is how far the
+// signature is from the major key on the same tonic (in fifths, ie. sharps),
+// and scale is the scale used to spell the notes (pentatonic scales use the
+// full major or minor one)
+var keyModes = map[Mode]struct {
+	fifths int
+	scale  Mode
+	minor  bool
+}{
+	ModeMajor:           {0, ModeMajor, false},
+	ModeIonian:          {0, ModeMajor, false},
+	ModeLydian:          {1, ModeLydian, false},
+	ModeMixolydian:      {-1, ModeMixolydian, false},
+	ModeDorian:          {-2, ModeDorian, false},
+	ModeMinor:           {-3, ModeMinor, true},
+	ModeAeolian:         {-3, ModeMinor, true},
+	ModeHarmonicMinor:   {-3, ModeHarmonicMinor, true},
+	ModeMelodicMinor:    {-3, ModeMelodicMinor, true},
+	ModePhrygian:        {-4, ModePhrygian, false},
+	ModeLocrian:         {-5, ModeLocrian, false},
+	ModeMajorPentatonic: {0, ModeMajor, false},
+	ModeMinorPentatonic: {-3, ModeMinor, true},
+	ModeBlues:           {-3, ModeMinor, true},
+}
+
+// letterFifths is the number of fifths from C to each letter (F is one fifth
+// below)
+var letterFifths = [7]int{0, 2, 4, -1, 1, 3, 5}
+
+// NewKey returns the key, or ErrUnknownMode. An empty mode is major
+func NewKey(tonic Pitch, mode Mode) (Key, error) {
+	if mode == "" {
+		mode = ModeMajor
+	}
+	if _, ok := keyModes[mode]; !ok {
+		return Key{}, fmt.Errorf("%q: %w", mode, ErrUnknownMode)
+	}
+	return Key{Tonic: tonic, Mode: mode}, nil
+}
+
+// KeySignature is the number of sharps (if it's positive) or flats (if it's
+// negative) at the start of the staff. Harmonic and melodic minor use the
+// signature of the natural minor. It can go past 7 for keys like G# major
+// (8 sharps: 6 sharps and a double sharp)
+func (k Key) KeySignature() int {
+	return letterFifths[k.Tonic.Letter] + 7*k.Tonic.Accidental + keyModes[k.mode()].fifths
+}
+
+func (k Key) mode() Mode {
+	if k.Mode == "" {
+		return ModeMajor
+	}
+	return k.Mode
+}
+
+// Spell writes the notes of the scale like they are in the scale (the blue
+// note is a b5), and the other ones with an accidental on a neighbouring note
+// of the scale:
+//   - in minor keys, the raised 6th and 7th (G# in A minor, C# in D minor)
+//   - otherwise, a natural if it can (B in F major, not Cb)
+//   - otherwise, sharps in sharp keys (and C major) and flats in flat keys
+func (k Key) Spell(index int) Pitch {
+	info := keyModes[k.mode()]
+	own, err := Scale(k.Tonic, k.mode())
+	if err != nil {
+		// Key wasn't created with NewKey
+		return Spell(index, Sharps)
+	}
+	tone := mod(index, 12)
+	for _, s := range own {
+		if mod(s.Index(), 12) == tone {
+			return octave(s, index)
+		}
+	}
+
+	scale, _ := Scale(k.Tonic, info.scale)
+	var best Pitch
+	bestScore := -1
+	for degree, s := range scale {
+		diff := mod(tone-s.Index()+6, 12) - 6
+		if diff < -1 || diff > 1 {
+			continue
+		}
+		p := Pitch{Letter: s.Letter, Accidental: s.Accidental + diff}
+		if p.Accidental < -2 || p.Accidental > 2 {
+			continue
+		}
+		var score int
+		switch {
+		case diff == 0:
+			// in the full scale, but not in a pentatonic one
+			score = 4
+		case info.minor && diff == 1 && (degree == 5 || degree == 6):
+			score = 3
+		case p.Accidental == 0:
+			score = 2
+		case (diff == 1) == (k.KeySignature() >= 0):
+			score = 1
+		}
+		if score > bestScore {
+			best, bestScore = p, score
+		}
+	}
+	if bestScore == -1 {
+		return Spell(index, Sharps)
+	}
+	return octave(best, index)
+}
+
+// octave returns p in the octave which makes it the key at index (p must be
+// the same key, in some octave). The octave number goes with the letter, so it
+// might not be the same for B#3 and C4
+func octave(p Pitch, index int) Pitch {
+	p.Octave = 0
+	p.Octave = (index - p.Index()) / 12
+	return p
+}
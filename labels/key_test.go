@@ -0,0 +1,97 @@
+package labels
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKeySpelling(t *testing.T) {
+	labels := NewLabels(nil)
+	var rows = []struct {
+		tonic    string
+		mode     Mode
+		name     string
+		expected string
+	}{
+		{"F4", ModeMajor, "A#4", "Bb4"},
+		{"B4", ModeMajor, "Bb4", "A#4"},
+		{"F4", ModeMajor, "B4", "B4"},
+		{"F4", ModeMajor, "C#4", "Db4"},
+		{"G4", ModeMajor, "Db4", "C#4"},
+		{"C4", ModeMajor, "Gb4", "F#4"},
+		{"A4", ModeMinor, "Ab4", "G#4"},
+		{"D4", ModeMinor, "Db4", "C#4"},
+		{"D4", ModeMinor, "Cb4", "B3"},
+		{"D4", ModeMinor, "G#4", "Ab4"},
+		{"F#4", ModeMajor, "F4", "E#4"},
+		{"Gb4", ModeMajor, "B4", "Cb5"},
+		{"Gb4", ModeMajor, "E4", "E4"},
+		{"A4", ModeHarmonicMinor, "G4", "G4"},
+		{"A4", ModeBlues, "D#5", "Eb5"},
+		{"D4", ModeDorian, "B4", "B4"},
+	}
+	for _, row := range rows {
+		tonic, err := labels.Pitch(row.tonic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := NewKey(tonic, row.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		label, _, _, err := labels.Label(labels.F(row.name), key)
+		if err != nil || label != row.expected {
+			t.Errorf("%s in %s %s, actual: %q (%v), expected: %q", row.name, row.tonic, row.mode, label, err, row.expected)
+		}
+	}
+
+	f, _ := NewKey(Pitch{Letter: 3}, ModeMajor)
+	b, _ := NewKey(Pitch{Letter: 6}, ModeMajor)
+	for key, expected := range map[Key]string{f: "Bb4", b: "A#4"} {
+		if label, _, _, _ := labels.Label(466.1638, key); label != expected {
+			t.Errorf("466 Hz in %v, actual: %q, expected: %q", key, label, expected)
+		}
+	}
+}
+
+func TestKeySignature(t *testing.T) {
+	labels := NewLabels(nil)
+	var rows = []struct {
+		tonic    string
+		mode     Mode
+		expected int
+	}{
+		{"C4", ModeMajor, 0},
+		{"G4", ModeMajor, 1},
+		{"F4", "", -1},
+		{"B4", ModeMajor, 5},
+		{"C#4", ModeMajor, 7},
+		{"Cb4", ModeMajor, -7},
+		{"A4", ModeMinor, 0},
+		{"E4", ModeHarmonicMinor, 1},
+		{"C4", ModeMinor, -3},
+		{"D4", ModeDorian, 0},
+		{"E4", ModePhrygian, 0},
+		{"F4", ModeLydian, 0},
+		{"G4", ModeMixolydian, 0},
+		{"B4", ModeLocrian, 0},
+		{"G#4", ModeMajor, 8},
+	}
+	for _, row := range rows {
+		tonic, err := labels.Pitch(row.tonic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := NewKey(tonic, row.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := key.KeySignature(); actual != row.expected {
+			t.Errorf("%s %s, actual: %d, expected: %d", row.tonic, row.mode, actual, row.expected)
+		}
+	}
+
+	if _, err := NewKey(Pitch{}, "bebop"); !errors.Is(err, ErrUnknownMode) {
+		t.Errorf("bebop, actual: %v, expected: %v", err, ErrUnknownMode)
+	}
+}
@@ -4,116 +4,231 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"strconv"
-	"unicode"
+	"sync"
 )
 
-// this should just generate some go code defining constants
-
 var ErrParsingName = errors.New("parsing name")
-var tones = map[string]int{
-	"C":  0,
-	"B#": 12, // because we go up an octave
-
-	"C#": 1,
-	"Db": 1,
-
-	"D": 2,
-
-	"D#": 3,
-	"Eb": 3,
-
-	"E":  4,
-	"Fb": 4,
-
-	"F":  5,
-	"E#": 5,
-
-	"F#": 6,
-	"Gb": 6,
+var ErrInvalidFrequency = errors.New("invalid frequency")
 
-	"G": 7,
+// Labels can be shared by several goroutines (it caches things, but it's
+// synchronized)
+type Labels struct {
+	// mu protects everything below
+	mu     sync.RWMutex
+	naming Naming
+	// names caches names to index, for every naming we've used
+	names  map[Naming]map[string]int
+	tuning Tuning
+	// freqs caches index to frequencies, for every tuning we've used
+	freqs map[Tuning]map[int]float64
+}
 
-	"G#": 8,
-	"Ab": 8,
+// NewLabels returns labels using the given tuning. nil means Standard (12-TET,
+// A4 = 440 Hz)
+func NewLabels(tuning Tuning) *Labels {
+	n := &Labels{
+		names: make(map[Naming]map[string]int),
+		freqs: make(map[Tuning]map[int]float64),
+	}
+	n.SetTuning(tuning)
+	n.SetNaming(nil)
+	return n
+}
 
-	"A": 9,
+// SetNaming changes how names are parsed and written. nil means Scientific.
+// Like for tunings, the cache of the previous naming is kept
+func (n *Labels) SetNaming(naming Naming) {
+	if naming == nil {
+		naming = Scientific
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.naming = naming
+	if _, ok := n.names[naming]; !ok {
+		n.names[naming] = make(map[string]int)
+	}
+}
 
-	"A#": 10,
-	"Bb": 10,
+func (n *Labels) Naming() Naming {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.naming
+}
 
-	"B":  11,
-	"Cb": -1, // because we go down an octave
+// SetTuning changes the tuning used to compute frequencies. nil means
+// Standard. The cache of the previous tuning is kept, so switching back and
+// forth is cheap
+func (n *Labels) SetTuning(tuning Tuning) {
+	if tuning == nil {
+		tuning = Standard
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.tuning = tuning
+	if _, ok := n.freqs[tuning]; !ok {
+		n.freqs[tuning] = make(map[int]float64)
+	}
 }
 
-type Labels struct {
-	// names caches names to index
-	names map[string]int
-	// freqs caches index to frequencies
-	freqs map[int]float64
+func (n *Labels) Tuning() Tuning {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.tuning
 }
 
-func NewLabels() *Labels {
-	return &Labels{
-		names: make(map[string]int),
-		freqs: make(map[int]float64),
-	}
+// current returns both the naming and the tuning, so that a method uses the
+// same ones all the way through even if another goroutine changes them
+func (n *Labels) current() (Naming, Tuning) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.naming, n.tuning
 }
 
 func (n *Labels) FromIndex(i int) (freq float64) {
-	if freq, ok := n.freqs[i]; ok {
+	return n.frequency(n.Tuning(), i)
+}
+
+// frequency returns the frequency of the key at index i in the tuning (which
+// has to have been set at some point)
+func (n *Labels) frequency(tuning Tuning, i int) float64 {
+	n.mu.RLock()
+	freq, ok := n.freqs[tuning][i]
+	n.mu.RUnlock()
+	if ok {
 		return freq
 	}
-	n.freqs[i] = math.Pow(2, (float64(i)-49)/12) * 440
-	return n.freqs[i]
+	// two goroutines might compute it at the same time, that's fine, they get
+	// the same thing
+	freq = tuning.Frequency(i)
+	n.mu.Lock()
+	n.freqs[tuning][i] = freq
+	n.mu.Unlock()
+	return freq
 }
 
+// name returns the index of the key, parsing the name with the current naming
 func (n *Labels) name(name string) (index int, err error) {
-	if len(name) != 2 && len(name) != 3 {
-		return 0, fmt.Errorf("invalid length (need 2 or 3) in %q: %w", name, ErrParsingName)
-	}
-
-	name = fmt.Sprintf("%c%s", unicode.ToUpper(rune(name[0])), name[1:])
+	return n.index(n.Naming(), name)
+}
 
-	if index, ok := n.names[name]; ok {
+// index returns the index of the key, parsing the name with naming (which has
+// to have been set at some point)
+func (n *Labels) index(naming Naming, name string) (int, error) {
+	n.mu.RLock()
+	index, ok := n.names[naming][name]
+	n.mu.RUnlock()
+	if ok {
 		return index, nil
 	}
-
-	var octave int
-
-	octave, err = strconv.Atoi(string(name[len(name)-1]))
+	p, err := naming.Parse(name)
 	if err != nil {
-		return 0, fmt.Errorf("parsing octave in %q: %s (%w)", name, err, ErrParsingName)
-	}
-
-	toneIndex, ok := tones[name[:len(name)-1]]
-	if !ok {
-		return 0, fmt.Errorf("parsing tone in %q: unknown tone (%w)", name, ErrParsingName)
+		return 0, err
 	}
-
-	n.names[name] = octave*12 + toneIndex - 8
-	return n.names[name], nil
+	n.mu.Lock()
+	n.names[naming][name] = p.Index()
+	n.mu.Unlock()
+	return p.Index(), nil
 }
 
 func (n *Labels) Frequency(name string) (freq float64, err error) {
-	index, err := n.name(name)
+	naming, tuning := n.current()
+	index, err := n.index(naming, name)
 	if err != nil {
 		return 0, err
 	}
-	return n.FromIndex(index), nil
+	return n.frequency(tuning, index), nil
 }
 
 // F is the same as Frequency, except it panics if there is an error. Don't
 // pass in user data, just static strings
 func (n *Labels) F(name string) float64 {
-	index, err := n.name(name)
+	freq, err := n.Frequency(name)
 	if err != nil {
 		panic(fmt.Sprintf("%s (if you don't want panic, use .Frequency instead)", err))
 	}
-	return n.FromIndex(index)
+	return freq
+}
+
+// Pitch parses the name with the current naming
+func (n *Labels) Pitch(name string) (Pitch, error) {
+	return n.Naming().Parse(name)
+}
+
+// Names writes the pitches with the current naming
+func (n *Labels) Names(pitches []Pitch) []string {
+	naming := n.Naming()
+	names := make([]string, len(pitches))
+	for i, p := range pitches {
+		names[i] = naming.Format(p)
+	}
+	return names
+}
+
+// Frequencies returns the frequency of every pitch in the current tuning (for
+// Scale and Chord)
+func (n *Labels) Frequencies(pitches []Pitch) []float64 {
+	tuning := n.Tuning()
+	freqs := make([]float64, len(pitches))
+	for i, p := range pitches {
+		freqs[i] = n.frequency(tuning, p.Index())
+	}
+	return freqs
+}
+
+// Speller chooses how to write the key at index: C#4 or Db4, and even B#3 or
+// Cb4. Spelling and Key are spellers
+type Speller interface {
+	Spell(index int) Pitch
+}
+
+// Spelling chooses how the black keys are named
+type Spelling int
+
+const (
+	Sharps Spelling = iota
+	Flats
+)
+
+func (s Spelling) Spell(index int) Pitch {
+	return Spell(index, s)
+}
+
+// Label returns the name of the key closest to freq, its index (like
+// FromIndex) and how far freq is from that key, in cents (between -50 and
+// 50).
+func (n *Labels) Label(freq float64, s Speller) (label string, index int, cents float64, err error) {
+	if !(freq > 0) || math.IsInf(freq, 1) {
+		return "", 0, 0, fmt.Errorf("%f: %w", freq, ErrInvalidFrequency)
+	}
+	naming, tuning := n.current()
+	index = n.nearest(tuning, freq)
+	cents = 1200 * math.Log2(freq/n.frequency(tuning, index))
+	return naming.Format(s.Spell(index)), index, cents, nil
 }
 
-// todo
-// func (n *labels) Label(freq float64) (string, error) {
+// nearest returns the index of the key closest to freq in the tuning
+func (n *Labels) nearest(tuning Tuning, freq float64) int {
+	// start from where it would be in 12-TET at 440, and then move to
+	// whichever neighbour is closer until we can't get any closer (the
+	// concert pitch might be really different, so it could take a few steps)
+	index := int(math.Round(49 + 12*math.Log2(freq/440)))
+	distance := func(i int) float64 {
+		return math.Abs(math.Log2(freq / n.frequency(tuning, i)))
+	}
+	// bounded, just in case the tuning is weird
+	for i := 0; i < 128; i++ {
+		if distance(index-1) < distance(index) {
+			index--
+		} else if distance(index+1) < distance(index) {
+			index++
+		} else {
+			break
+		}
+	}
+	return index
+}
 
-// }
+// mod is like %, but always returns a positive number
+func mod(a, b int) int {
+	return (a%b + b) % b
+}
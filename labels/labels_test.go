@@ -1,7 +1,10 @@
 package labels
 
 import (
+	"errors"
+	"fmt"
 	"math"
+	"strings"
 	"testing"
 )
 
@@ -41,7 +44,7 @@ func TestLabelsCases(t *testing.T) {
 		{"E7", 2637.020},
 	}
 
-	labels := NewLabels()
+	labels := NewLabels(nil)
 
 	for _, obj := range names_freq {
 		actual, err := labels.Frequency(obj.name)
@@ -62,3 +65,119 @@ func TestLabelsCases(t *testing.T) {
 func roundTo(n float64, dp int) float64 {
 	return math.Round(n*math.Pow(10, float64(dp))) / math.Pow(10, float64(dp))
 }
+
+func TestLabel(t *testing.T) {
+	var rows = []struct {
+		freq     float64
+		spelling Spelling
+		label    string
+		index    int
+		cents    float64
+	}{
+		{440, Sharps, "A4", 49, 0},
+		{27.5, Sharps, "A0", 1, 0},
+		{261.6256, Flats, "C4", 40, 0},
+		{466.1638, Sharps, "A#4", 50, 0},
+		{466.1638, Flats, "Bb4", 50, 0},
+		// 523 isn't quite C5
+		{523, Sharps, "C5", 52, -0.83},
+		// a bit closer to A4 than to A#4
+		{452, Flats, "A4", 49, 46.58},
+		{454, Flats, "Bb4", 50, -45.77},
+		{8.1758, Sharps, "C-1", -20, 0},
+		{4186.009, Sharps, "C8", 88, 0},
+	}
+
+	labels := NewLabels(nil)
+	for _, row := range rows {
+		label, index, cents, err := labels.Label(row.freq, row.spelling)
+		if err != nil {
+			t.Errorf("freq: %f, expected: pass, got err: %s", row.freq, err)
+			continue
+		}
+		if label != row.label || index != row.index || roundTo(cents, 2) != row.cents {
+			t.Errorf("freq: %f, actual: %s %d %.2f, expected: %s %d %.2f", row.freq, label, index, cents, row.label, row.index, row.cents)
+		}
+	}
+
+	for _, freq := range []float64{0, -440, math.NaN(), math.Inf(1)} {
+		if _, _, _, err := labels.Label(freq, Sharps); !errors.Is(err, ErrInvalidFrequency) {
+			t.Errorf("freq: %f, actual: %v, expected: %v", freq, err, ErrInvalidFrequency)
+		}
+	}
+}
+
+func TestNameGrammar(t *testing.T) {
+	var rows = []struct {
+		name  string
+		index int
+	}{
+		{"A4", 49},
+		{"a4", 49},
+		{"bb3", 38},
+		{"C#4", 41},
+		{"C♯4", 41},
+		{"C##4", 42},
+		{"Cx4", 42},
+		{"C𝄪4", 42},
+		{"Bbb3", 37},
+		{"B♭♭3", 37},
+		{"B𝄫3", 37},
+		{"A♭2", 24},
+		{"C10", 112},
+		{"C-1", -20},
+		{"Cb-1", -21},
+		{"C0", -8},
+		// the old edge cases
+		{"B#3", 40},
+		{"Cb4", 39},
+		{"E#4", 45},
+		{"Fb4", 44},
+		{"B##3", 41},
+		{"Cbb4", 38},
+	}
+	labels := NewLabels(nil)
+	for _, row := range rows {
+		actual, err := labels.name(row.name)
+		if err != nil {
+			t.Errorf("name: %q, expected: pass, got err: %s", row.name, err)
+			continue
+		}
+		if actual != row.index {
+			t.Errorf("name: %q, actual: %d, expected: %d", row.name, actual, row.index)
+		}
+	}
+}
+
+func TestNameGrammarErrors(t *testing.T) {
+	var rows = []struct {
+		name string
+		// the error message should point at that byte
+		pos int
+	}{
+		{"", 0},
+		{"H4", 0},
+		{"C", 1},
+		{"C#", 2},
+		{"C#b4", 2},
+		{"C###4", 3},
+		{"Cbbb4", 3},
+		{"C♯♯♯4", 7},
+		{"C4.5", 2},
+		{"C-", 2},
+		{"C--1", 2},
+		{"C 4", 1},
+		{"C99999999999999999999", 1},
+	}
+	labels := NewLabels(nil)
+	for _, row := range rows {
+		_, err := labels.Frequency(row.name)
+		if !errors.Is(err, ErrParsingName) {
+			t.Errorf("name: %q, actual: %v, expected: %v", row.name, err, ErrParsingName)
+			continue
+		}
+		if !strings.Contains(err.Error(), fmt.Sprintf("at byte %d:", row.pos)) {
+			t.Errorf("name: %q, actual: %q, expected: at byte %d", row.name, err, row.pos)
+		}
+	}
+}
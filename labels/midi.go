@@ -0,0 +1,76 @@
+package labels
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrOutOfRange = errors.New("out of range")
+
+// MIDI note numbers go from 0 (C-1) to 127 (G9). They are the piano index
+// (FromIndex, A0 = 1, A4 = 49) plus 20, so A4 is 69.
+const (
+	MinMIDI = 0
+	MaxMIDI = 127
+
+	midiOffset = 20
+)
+
+// IndexToMIDI returns the MIDI note number of the key at index
+func IndexToMIDI(index int) (int, error) {
+	key := index + midiOffset
+	if key < MinMIDI || key > MaxMIDI {
+		return 0, fmt.Errorf("index %d is MIDI note %d: %w", index, key, ErrOutOfRange)
+	}
+	return key, nil
+}
+
+// MIDIToIndex returns the piano index (like FromIndex takes) of the MIDI note
+func MIDIToIndex(key int) (int, error) {
+	if key < MinMIDI || key > MaxMIDI {
+		return 0, fmt.Errorf("MIDI note %d: %w", key, ErrOutOfRange)
+	}
+	return key - midiOffset, nil
+}
+
+// FromMIDI returns the frequency of the MIDI note in the current tuning
+func (n *Labels) FromMIDI(key int) (float64, error) {
+	index, err := MIDIToIndex(key)
+	if err != nil {
+		return 0, err
+	}
+	return n.FromIndex(index), nil
+}
+
+// MIDI returns the MIDI note number of the name (parsed with the current
+// naming)
+func (n *Labels) MIDI(name string) (int, error) {
+	index, err := n.name(name)
+	if err != nil {
+		return 0, err
+	}
+	return IndexToMIDI(index)
+}
+
+// MIDILabel returns the name of the MIDI note, with the current naming
+func (n *Labels) MIDILabel(key int, s Speller) (string, error) {
+	index, err := MIDIToIndex(key)
+	if err != nil {
+		return "", err
+	}
+	return n.Naming().Format(s.Spell(index)), nil
+}
+
+// NearestMIDI returns the MIDI note closest to freq in the current tuning, and
+// how far (in cents) freq is from it
+func (n *Labels) NearestMIDI(freq float64) (key int, cents float64, err error) {
+	_, index, cents, err := n.Label(freq, Sharps)
+	if err != nil {
+		return 0, 0, err
+	}
+	key, err = IndexToMIDI(index)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%f Hz: %w", freq, err)
+	}
+	return key, cents, nil
+}
@@ -0,0 +1,83 @@
+package labels
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMIDI(t *testing.T) {
+	labels := NewLabels(nil)
+	var rows = []struct {
+		name  string
+		index int
+		key   int
+		freq  float64
+	}{
+		{"C-1", -20, 0, 8.1758},
+		{"A0", 1, 21, 27.5},
+		{"C4", 40, 60, 261.6256},
+		{"A4", 49, 69, 440},
+		{"C8", 88, 108, 4186.0090},
+		{"G9", 107, 127, 12543.8540},
+	}
+	for _, row := range rows {
+		key, err := IndexToMIDI(row.index)
+		if err != nil || key != row.key {
+			t.Errorf("IndexToMIDI(%d), actual: %d (%v), expected: %d", row.index, key, err, row.key)
+		}
+		index, err := MIDIToIndex(row.key)
+		if err != nil || index != row.index {
+			t.Errorf("MIDIToIndex(%d), actual: %d (%v), expected: %d", row.key, index, err, row.index)
+		}
+		key, err = labels.MIDI(row.name)
+		if err != nil || key != row.key {
+			t.Errorf("MIDI(%q), actual: %d (%v), expected: %d", row.name, key, err, row.key)
+		}
+		name, err := labels.MIDILabel(row.key, Sharps)
+		if err != nil || name != row.name {
+			t.Errorf("MIDILabel(%d), actual: %q (%v), expected: %q", row.key, name, err, row.name)
+		}
+		freq, err := labels.FromMIDI(row.key)
+		if err != nil || math.Abs(freq-row.freq) > 1e-3 {
+			t.Errorf("FromMIDI(%d), actual: %f (%v), expected: %f", row.key, freq, err, row.freq)
+		}
+		key, cents, err := labels.NearestMIDI(row.freq)
+		if err != nil || key != row.key || math.Abs(cents) > 0.01 {
+			t.Errorf("NearestMIDI(%f), actual: %d %+.2fc (%v), expected: %d", row.freq, key, cents, err, row.key)
+		}
+	}
+
+	key, cents, err := labels.NearestMIDI(452)
+	if err != nil || key != 69 || math.Abs(cents-46.58) > 0.01 {
+		t.Errorf("NearestMIDI(452), actual: %d %+.2fc (%v), expected: 69 +46.58c", key, cents, err)
+	}
+}
+
+func TestMIDIOutOfRange(t *testing.T) {
+	labels := NewLabels(nil)
+	if _, err := IndexToMIDI(-21); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("IndexToMIDI(-21), actual: %v, expected: %v", err, ErrOutOfRange)
+	}
+	if _, err := IndexToMIDI(108); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("IndexToMIDI(108), actual: %v, expected: %v", err, ErrOutOfRange)
+	}
+	if _, err := MIDIToIndex(128); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("MIDIToIndex(128), actual: %v, expected: %v", err, ErrOutOfRange)
+	}
+	if _, err := labels.FromMIDI(-1); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("FromMIDI(-1), actual: %v, expected: %v", err, ErrOutOfRange)
+	}
+	if _, err := labels.MIDI("A9"); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("MIDI(A9), actual: %v, expected: %v", err, ErrOutOfRange)
+	}
+	if _, err := labels.MIDILabel(200, Sharps); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("MIDILabel(200), actual: %v, expected: %v", err, ErrOutOfRange)
+	}
+	if _, _, err := labels.NearestMIDI(20000); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("NearestMIDI(20000), actual: %v, expected: %v", err, ErrOutOfRange)
+	}
+	if _, _, err := labels.NearestMIDI(-1); !errors.Is(err, ErrInvalidFrequency) {
+		t.Errorf("NearestMIDI(-1), actual: %v, expected: %v", err, ErrInvalidFrequency)
+	}
+}
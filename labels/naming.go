@@ -0,0 +1,226 @@
+package labels
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Naming is a system to write note names, like scientific pitch notation (A4)
+// or solfège (La4).
+//
+// Like tunings, Labels uses namings as map keys, so they have to be
+// comparable, and they can be called from several goroutines at once.
+type Naming interface {
+	Parse(name string) (Pitch, error)
+	Format(p Pitch) string
+}
+
+var (
+	// Scientific is scientific pitch notation: C#4, Bb3, Fx2, C-1 (see
+	// parseScientific). It's the default.
+	Scientific Naming = scientific{}
+	// Solfege is fixed do: Do4, Re#4, Sib3 (Ti is accepted for Si)
+	Solfege Naming = solfege{}
+	// German uses H for B and B for Bb, and the -is/-es suffixes: Cis4, Es4,
+	// B3, H3
+	German Naming = german{}
+	// Helmholtz gives the octave with the case and marks: C, is C1, C is C2,
+	// c is C3, c' is C4 and c'' is C5
+	Helmholtz Naming = helmholtz{}
+)
+
+// Convert rewrites a name from a naming system into another, keeping the
+// spelling (Db stays Db)
+func Convert(name string, from, to Naming) (string, error) {
+	p, err := from.Parse(name)
+	if err != nil {
+		return "", err
+	}
+	return to.Format(p), nil
+}
+
+// Spell returns the pitch for the key at index, with a sharp or a flat for
+// the black keys
+func Spell(index int, s Spelling) Pitch {
+	// index 40 is C4, so shift everything to get C0 at 0
+	tone := mod(index+8, 12)
+	octave := (index + 8 - tone) / 12
+
+	// the highest natural that isn't above the tone
+	p := Pitch{Octave: octave, Letter: len(letterTones) - 1}
+	for letterTones[p.Letter] > tone {
+		p.Letter--
+	}
+	p.Accidental = tone - letterTones[p.Letter]
+	if p.Accidental != 0 && s == Flats {
+		p.Letter++
+		p.Accidental = tone - letterTones[p.Letter]
+	}
+	return p
+}
+
+// accidentalString writes the accidental with #, ## and b, bb
+func accidentalString(accidental int) string {
+	if accidental > 0 {
+		return strings.Repeat("#", accidental)
+	}
+	return strings.Repeat("b", -accidental)
+}
+
+type scientific struct{}
+
+func (scientific) Parse(name string) (Pitch, error) {
+	return parseScientific(name)
+}
+
+func (scientific) Format(p Pitch) string {
+	return fmt.Sprintf("%c%s%d", letterNames[p.Letter], accidentalString(p.Accidental), p.Octave)
+}
+
+var syllables = [7]string{"Do", "Re", "Mi", "Fa", "Sol", "La", "Si"}
+
+type solfege struct{}
+
+func (solfege) Parse(name string) (p Pitch, err error) {
+	pos := -1
+	for letter, syllable := range syllables {
+		if len(name) >= len(syllable) && strings.EqualFold(name[:len(syllable)], syllable) {
+			p.Letter, pos = letter, len(syllable)
+			break
+		}
+	}
+	if pos == -1 && len(name) >= 2 && strings.EqualFold(name[:2], "Ti") {
+		p.Letter, pos = 6, 2
+	}
+	if pos == -1 {
+		return p, parseError(name, 0, "expected a syllable (Do, Re, Mi, Fa, Sol, La or Si)")
+	}
+
+	p.Accidental, pos, err = parseAccidentals(name, pos)
+	if err != nil {
+		return p, err
+	}
+	p.Octave, err = parseOctave(name, pos)
+	return p, err
+}
+
+func (solfege) Format(p Pitch) string {
+	return fmt.Sprintf("%s%s%d", syllables[p.Letter], accidentalString(p.Accidental), p.Octave)
+}
+
+type german struct{}
+
+func (german) Parse(name string) (p Pitch, err error) {
+	if len(name) == 0 {
+		return p, parseError(name, 0, "expected a letter from A to H")
+	}
+	pos := 1
+	switch c := name[0]; {
+	case c == 'H' || c == 'h':
+		p.Letter = 6
+	case c == 'B' || c == 'b':
+		// B is a B flat, and can't have any other accidental
+		p.Letter, p.Accidental = 6, -1
+		p.Octave, err = parseOctave(name, pos)
+		return p, err
+	default:
+		var ok bool
+		if p.Letter, ok = parseLetter(c); !ok {
+			return p, parseError(name, 0, "expected a letter from A to H")
+		}
+	}
+
+	// Es and As are short for Ees and Aes
+	if (p.Letter == 2 || p.Letter == 5) && pos < len(name) && name[pos] == 's' {
+		p.Accidental = -1
+		pos++
+	}
+	for pos+1 < len(name) && (name[pos:pos+2] == "is" || name[pos:pos+2] == "es") {
+		semitones := 1
+		if name[pos] == 'e' {
+			semitones = -1
+		}
+		if p.Accidental != 0 && (p.Accidental > 0) != (semitones > 0) {
+			return p, parseError(name, pos, "can't mix sharps and flats")
+		}
+		p.Accidental += semitones
+		if p.Accidental > 2 || p.Accidental < -2 {
+			return p, parseError(name, pos, "more than a double accidental")
+		}
+		pos += 2
+	}
+	p.Octave, err = parseOctave(name, pos)
+	return p, err
+}
+
+func (german) Format(p Pitch) string {
+	letter := string(letterNames[p.Letter])
+	if p.Letter == 6 {
+		letter = "H"
+		if p.Accidental == -1 {
+			return fmt.Sprintf("B%d", p.Octave)
+		}
+	}
+	var suffix string
+	if p.Accidental > 0 {
+		suffix = strings.Repeat("is", p.Accidental)
+	} else if p.Accidental < 0 {
+		suffix = strings.Repeat("es", -p.Accidental)
+		if p.Letter == 2 || p.Letter == 5 {
+			// Es, As, Eses, Ases
+			suffix = suffix[1:]
+		}
+	}
+	return fmt.Sprintf("%s%s%d", letter, suffix, p.Octave)
+}
+
+type helmholtz struct{}
+
+func (helmholtz) Parse(name string) (p Pitch, err error) {
+	if len(name) == 0 {
+		return p, parseError(name, 0, "expected a letter from A to G")
+	}
+	var ok bool
+	if p.Letter, ok = parseLetter(name[0]); !ok {
+		return p, parseError(name, 0, "expected a letter from A to G")
+	}
+	lower := name[0] >= 'a'
+
+	var pos int
+	p.Accidental, pos, err = parseAccidentals(name, 1)
+	if err != nil {
+		return p, err
+	}
+
+	// the small octave (lower case, no mark) is octave 3, the great octave
+	// (upper case) is 2
+	p.Octave = 2
+	if lower {
+		p.Octave = 3
+	}
+	for pos < len(name) {
+		r, size := utf8.DecodeRuneInString(name[pos:])
+		switch {
+		case (r == '\'' || r == '′') && lower:
+			p.Octave++
+		case r == ',' && !lower:
+			p.Octave--
+		case r == '\'' || r == '′':
+			return p, parseError(name, pos, "primes only go after lower case letters")
+		case r == ',':
+			return p, parseError(name, pos, "commas only go after upper case letters")
+		default:
+			return p, parseError(name, pos, "expected ' or ,")
+		}
+		pos += size
+	}
+	return p, nil
+}
+
+func (helmholtz) Format(p Pitch) string {
+	if p.Octave >= 3 {
+		return fmt.Sprintf("%c%s%s", letterNames[p.Letter]+'a'-'A', accidentalString(p.Accidental), strings.Repeat("'", p.Octave-3))
+	}
+	return fmt.Sprintf("%c%s%s", letterNames[p.Letter], accidentalString(p.Accidental), strings.Repeat(",", 2-p.Octave))
+}
@@ -0,0 +1,134 @@
+package labels
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNamings(t *testing.T) {
+	var rows = []struct {
+		naming Naming
+		name   string
+		// what it is in scientific pitch notation
+		scientific string
+	}{
+		{Solfege, "Do4", "C4"},
+		{Solfege, "do4", "C4"},
+		{Solfege, "Sol3", "G3"},
+		{Solfege, "Sib3", "Bb3"},
+		{Solfege, "Ti3", "B3"},
+		{Solfege, "Fa#2", "F#2"},
+		{Solfege, "La♭-1", "Ab-1"},
+
+		{German, "H3", "B3"},
+		{German, "B3", "Bb3"},
+		{German, "Cis4", "C#4"},
+		{German, "Es4", "Eb4"},
+		{German, "As2", "Ab2"},
+		{German, "Eses2", "Ebb2"},
+		{German, "Fisis5", "F##5"},
+		{German, "Des4", "Db4"},
+		{German, "Heses3", "Bbb3"},
+		{German, "His3", "B#3"},
+
+		{Helmholtz, "c'", "C4"},
+		{Helmholtz, "c", "C3"},
+		{Helmholtz, "C", "C2"},
+		{Helmholtz, "C,", "C1"},
+		{Helmholtz, "A,,", "A0"},
+		{Helmholtz, "a'", "A4"},
+		{Helmholtz, "f#''", "F#5"},
+		{Helmholtz, "bb′", "Bb4"},
+	}
+
+	for _, row := range rows {
+		actual, err := Convert(row.name, row.naming, Scientific)
+		if err != nil {
+			t.Errorf("name: %q, expected: pass, got err: %s", row.name, err)
+			continue
+		}
+		if actual != row.scientific {
+			t.Errorf("name: %q, actual: %q, expected: %q", row.name, actual, row.scientific)
+		}
+	}
+}
+
+func TestNamingsFormat(t *testing.T) {
+	var rows = []struct {
+		naming     Naming
+		scientific string
+		name       string
+	}{
+		{Solfege, "C#4", "Do#4"},
+		{Solfege, "G3", "Sol3"},
+		{German, "B3", "H3"},
+		{German, "Bb3", "B3"},
+		{German, "Bbb3", "Heses3"},
+		{German, "Eb4", "Es4"},
+		{German, "Abb4", "Ases4"},
+		{German, "G#4", "Gis4"},
+		{German, "Dbb4", "Deses4"},
+		{Helmholtz, "C4", "c'"},
+		{Helmholtz, "Bb3", "bb"},
+		{Helmholtz, "E1", "E,"},
+		{Helmholtz, "G#6", "g#'''"},
+	}
+	for _, row := range rows {
+		actual, err := Convert(row.scientific, Scientific, row.naming)
+		if err != nil {
+			t.Errorf("name: %q, expected: pass, got err: %s", row.scientific, err)
+			continue
+		}
+		if actual != row.name {
+			t.Errorf("name: %q, actual: %q, expected: %q", row.scientific, actual, row.name)
+		}
+		// and back
+		back, err := Convert(actual, row.naming, Scientific)
+		if err != nil || back != row.scientific {
+			t.Errorf("name: %q, back: %q (%v), expected: %q", actual, back, err, row.scientific)
+		}
+	}
+}
+
+func TestNamingsErrors(t *testing.T) {
+	var rows = []struct {
+		naming Naming
+		name   string
+	}{
+		{Solfege, "Ut4"},
+		{Solfege, "Do"},
+		{German, "B#3"},
+		{German, "Cises4"},
+		{German, "Cisisis4"},
+		{German, ""},
+		{Helmholtz, "C'"},
+		{Helmholtz, "c,"},
+		{Helmholtz, "c4"},
+		{Helmholtz, ""},
+	}
+	for _, row := range rows {
+		if _, err := row.naming.Parse(row.name); !errors.Is(err, ErrParsingName) {
+			t.Errorf("name: %q, actual: %v, expected: %v", row.name, err, ErrParsingName)
+		}
+	}
+}
+
+func TestLabelsNaming(t *testing.T) {
+	labels := NewLabels(nil)
+	labels.SetNaming(Solfege)
+	if actual := labels.F("La4"); actual != 440 {
+		t.Errorf("La4, actual: %f, expected: %f", actual, 440.0)
+	}
+	label, _, _, err := labels.Label(466.1638, Flats)
+	if err != nil || label != "Sib4" {
+		t.Errorf("label of 466.1638, actual: %q (%v), expected: %q", label, err, "Sib4")
+	}
+
+	// the cache is per naming, "B3" doesn't mean the same thing in German
+	labels.SetNaming(nil)
+	b := labels.F("B3")
+	labels.SetNaming(German)
+	if actual := labels.F("B3"); actual == b {
+		t.Errorf("German B3 is the same as scientific B3: %f", actual)
+	}
+}
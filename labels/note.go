@@ -0,0 +1,36 @@
+package labels
+
+//go:generate go run ./internal/notegen -o notes_gen.go
+
+// Note is a MIDI note number. There's a constant for every one of them (A4,
+// Cs5 or Db5, CNeg1 for C-1, see notes_gen.go), so that pieces written in Go
+// don't need a *Labels, and can't get a name wrong. Cs5 and Db5 are the same
+// Note, so they have the same label (with a sharp). The methods panic for
+// numbers which aren't MIDI notes (from 0 to 127).
+type Note int
+
+// Frequency is in 12-TET, with A4 at 440 Hz (like Standard). Use a *Labels
+// for other tunings.
+func (n Note) Frequency() float64 {
+	return noteFrequencies[n]
+}
+
+func (n Note) MIDI() int {
+	return int(n)
+}
+
+// Index returns the piano index, like FromIndex takes
+func (n Note) Index() int {
+	return int(n) - midiOffset
+}
+
+// Label is the name in scientific pitch notation, always with sharps: the
+// flat constants are the same numbers as the sharp ones, so Db5.Label() is
+// "C#5". Use Spell(n.Index(), Flats) to get a flat.
+func (n Note) Label() string {
+	return noteLabels[n]
+}
+
+func (n Note) String() string {
+	return n.Label()
+}
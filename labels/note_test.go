@@ -0,0 +1,37 @@
+package labels
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNote(t *testing.T) {
+	labels := NewLabels(nil)
+	for n := CNeg1; n <= G9; n++ {
+		name, err := labels.MIDILabel(n.MIDI(), Sharps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n.Label() != name {
+			t.Errorf("note %d, actual: %q, expected: %q", n, n.Label(), name)
+		}
+		freq := labels.F(name)
+		if math.Abs(n.Frequency()-freq) > 1e-9 {
+			t.Errorf("%s, actual: %f, expected: %f", name, n.Frequency(), freq)
+		}
+		if index, _ := MIDIToIndex(n.MIDI()); n.Index() != index {
+			t.Errorf("%s index, actual: %d, expected: %d", name, n.Index(), index)
+		}
+	}
+
+	if A4.Frequency() != 440 || A4.MIDI() != 69 || A4.Label() != "A4" {
+		t.Errorf("A4, actual: %f %d %q", A4.Frequency(), A4.MIDI(), A4.Label())
+	}
+	if Cs5 != Db5 || Cs5.String() != "C#5" {
+		t.Errorf("Cs5, actual: %d %q, expected: %d %q", Cs5, Cs5, Db5, "C#5")
+	}
+	// the flat has to be spelled
+	if actual := Scientific.Format(Spell(Db5.Index(), Flats)); Db5.Label() != "C#5" || actual != "Db5" {
+		t.Errorf("Db5, actual: %q %q, expected: %q %q", Db5.Label(), actual, "C#5", "Db5")
+	}
+}
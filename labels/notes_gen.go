@@ -0,0 +1,504 @@
+// Code generated by notegen; DO NOT EDIT.
+
+package labels
+
+// every MIDI note, with sharps and flats for the black keys
+const (
+	CNeg1  Note = 0
+	CsNeg1 Note = 1
+	// DbNeg1 is CsNeg1, so its Label is "C#-1"
+	DbNeg1 Note = 1
+	DNeg1  Note = 2
+	DsNeg1 Note = 3
+	// EbNeg1 is DsNeg1, so its Label is "D#-1"
+	EbNeg1 Note = 3
+	ENeg1  Note = 4
+	FNeg1  Note = 5
+	FsNeg1 Note = 6
+	// GbNeg1 is FsNeg1, so its Label is "F#-1"
+	GbNeg1 Note = 6
+	GNeg1  Note = 7
+	GsNeg1 Note = 8
+	// AbNeg1 is GsNeg1, so its Label is "G#-1"
+	AbNeg1 Note = 8
+	ANeg1  Note = 9
+	AsNeg1 Note = 10
+	// BbNeg1 is AsNeg1, so its Label is "A#-1"
+	BbNeg1 Note = 10
+	BNeg1  Note = 11
+	C0     Note = 12
+	Cs0    Note = 13
+	// Db0 is Cs0, so its Label is "C#0"
+	Db0 Note = 13
+	D0  Note = 14
+	Ds0 Note = 15
+	// Eb0 is Ds0, so its Label is "D#0"
+	Eb0 Note = 15
+	E0  Note = 16
+	F0  Note = 17
+	Fs0 Note = 18
+	// Gb0 is Fs0, so its Label is "F#0"
+	Gb0 Note = 18
+	G0  Note = 19
+	Gs0 Note = 20
+	// Ab0 is Gs0, so its Label is "G#0"
+	Ab0 Note = 20
+	A0  Note = 21
+	As0 Note = 22
+	// Bb0 is As0, so its Label is "A#0"
+	Bb0 Note = 22
+	B0  Note = 23
+	C1  Note = 24
+	Cs1 Note = 25
+	// Db1 is Cs1, so its Label is "C#1"
+	Db1 Note = 25
+	D1  Note = 26
+	Ds1 Note = 27
+	// Eb1 is Ds1, so its Label is "D#1"
+	Eb1 Note = 27
+	E1  Note = 28
+	F1  Note = 29
+	Fs1 Note = 30
+	// Gb1 is Fs1, so its Label is "F#1"
+	Gb1 Note = 30
+	G1  Note = 31
+	Gs1 Note = 32
+	// Ab1 is Gs1, so its Label is "G#1"
+	Ab1 Note = 32
+	A1  Note = 33
+	As1 Note = 34
+	// Bb1 is As1, so its Label is "A#1"
+	Bb1 Note = 34
+	B1  Note = 35
+	C2  Note = 36
+	Cs2 Note = 37
+	// Db2 is Cs2, so its Label is "C#2"
+	Db2 Note = 37
+	D2  Note = 38
+	Ds2 Note = 39
+	// Eb2 is Ds2, so its Label is "D#2"
+	Eb2 Note = 39
+	E2  Note = 40
+	F2  Note = 41
+	Fs2 Note = 42
+	// Gb2 is Fs2, so its Label is "F#2"
+	Gb2 Note = 42
+	G2  Note = 43
+	Gs2 Note = 44
+	// Ab2 is Gs2, so its Label is "G#2"
+	Ab2 Note = 44
+	A2  Note = 45
+	As2 Note = 46
+	// Bb2 is As2, so its Label is "A#2"
+	Bb2 Note = 46
+	B2  Note = 47
+	C3  Note = 48
+	Cs3 Note = 49
+	// Db3 is Cs3, so its Label is "C#3"
+	Db3 Note = 49
+	D3  Note = 50
+	Ds3 Note = 51
+	// Eb3 is Ds3, so its Label is "D#3"
+	Eb3 Note = 51
+	E3  Note = 52
+	F3  Note = 53
+	Fs3 Note = 54
+	// Gb3 is Fs3, so its Label is "F#3"
+	Gb3 Note = 54
+	G3  Note = 55
+	Gs3 Note = 56
+	// Ab3 is Gs3, so its Label is "G#3"
+	Ab3 Note = 56
+	A3  Note = 57
+	As3 Note = 58
+	// Bb3 is As3, so its Label is "A#3"
+	Bb3 Note = 58
+	B3  Note = 59
+	C4  Note = 60
+	Cs4 Note = 61
+	// Db4 is Cs4, so its Label is "C#4"
+	Db4 Note = 61
+	D4  Note = 62
+	Ds4 Note = 63
+	// Eb4 is Ds4, so its Label is "D#4"
+	Eb4 Note = 63
+	E4  Note = 64
+	F4  Note = 65
+	Fs4 Note = 66
+	// Gb4 is Fs4, so its Label is "F#4"
+	Gb4 Note = 66
+	G4  Note = 67
+	Gs4 Note = 68
+	// Ab4 is Gs4, so its Label is "G#4"
+	Ab4 Note = 68
+	A4  Note = 69
+	As4 Note = 70
+	// Bb4 is As4, so its Label is "A#4"
+	Bb4 Note = 70
+	B4  Note = 71
+	C5  Note = 72
+	Cs5 Note = 73
+	// Db5 is Cs5, so its Label is "C#5"
+	Db5 Note = 73
+	D5  Note = 74
+	Ds5 Note = 75
+	// Eb5 is Ds5, so its Label is "D#5"
+	Eb5 Note = 75
+	E5  Note = 76
+	F5  Note = 77
+	Fs5 Note = 78
+	// Gb5 is Fs5, so its Label is "F#5"
+	Gb5 Note = 78
+	G5  Note = 79
+	Gs5 Note = 80
+	// Ab5 is Gs5, so its Label is "G#5"
+	Ab5 Note = 80
+	A5  Note = 81
+	As5 Note = 82
+	// Bb5 is As5, so its Label is "A#5"
+	Bb5 Note = 82
+	B5  Note = 83
+	C6  Note = 84
+	Cs6 Note = 85
+	// Db6 is Cs6, so its Label is "C#6"
+	Db6 Note = 85
+	D6  Note = 86
+	Ds6 Note = 87
+	// Eb6 is Ds6, so its Label is "D#6"
+	Eb6 Note = 87
+	E6  Note = 88
+	F6  Note = 89
+	Fs6 Note = 90
+	// Gb6 is Fs6, so its Label is "F#6"
+	Gb6 Note = 90
+	G6  Note = 91
+	Gs6 Note = 92
+	// Ab6 is Gs6, so its Label is "G#6"
+	Ab6 Note = 92
+	A6  Note = 93
+	As6 Note = 94
+	// Bb6 is As6, so its Label is "A#6"
+	Bb6 Note = 94
+	B6  Note = 95
+	C7  Note = 96
+	Cs7 Note = 97
+	// Db7 is Cs7, so its Label is "C#7"
+	Db7 Note = 97
+	D7  Note = 98
+	Ds7 Note = 99
+	// Eb7 is Ds7, so its Label is "D#7"
+	Eb7 Note = 99
+	E7  Note = 100
+	F7  Note = 101
+	Fs7 Note = 102
+	// Gb7 is Fs7, so its Label is "F#7"
+	Gb7 Note = 102
+	G7  Note = 103
+	Gs7 Note = 104
+	// Ab7 is Gs7, so its Label is "G#7"
+	Ab7 Note = 104
+	A7  Note = 105
+	As7 Note = 106
+	// Bb7 is As7, so its Label is "A#7"
+	Bb7 Note = 106
+	B7  Note = 107
+	C8  Note = 108
+	Cs8 Note = 109
+	// Db8 is Cs8, so its Label is "C#8"
+	Db8 Note = 109
+	D8  Note = 110
+	Ds8 Note = 111
+	// Eb8 is Ds8, so its Label is "D#8"
+	Eb8 Note = 111
+	E8  Note = 112
+	F8  Note = 113
+	Fs8 Note = 114
+	// Gb8 is Fs8, so its Label is "F#8"
+	Gb8 Note = 114
+	G8  Note = 115
+	Gs8 Note = 116
+	// Ab8 is Gs8, so its Label is "G#8"
+	Ab8 Note = 116
+	A8  Note = 117
+	As8 Note = 118
+	// Bb8 is As8, so its Label is "A#8"
+	Bb8 Note = 118
+	B8  Note = 119
+	C9  Note = 120
+	Cs9 Note = 121
+	// Db9 is Cs9, so its Label is "C#9"
+	Db9 Note = 121
+	D9  Note = 122
+	Ds9 Note = 123
+	// Eb9 is Ds9, so its Label is "D#9"
+	Eb9 Note = 123
+	E9  Note = 124
+	F9  Note = 125
+	Fs9 Note = 126
+	// Gb9 is Fs9, so its Label is "F#9"
+	Gb9 Note = 126
+	G9  Note = 127
+)
+
+// noteFrequencies are in 12-TET, with A4 at 440 Hz
+var noteFrequencies = [128]float64{
+	8.175798915643707,
+	8.661957218027252,
+	9.177023997418988,
+	9.722718241315029,
+	10.300861153527183,
+	10.913382232281373,
+	11.562325709738577,
+	12.249857374429663,
+	12.978271799373287,
+	13.75,
+	14.567617547440307,
+	15.433853164253883,
+	16.351597831287414,
+	17.323914436054505,
+	18.354047994837977,
+	19.445436482630058,
+	20.601722307054366,
+	21.826764464562746,
+	23.124651419477154,
+	24.499714748859326,
+	25.956543598746574,
+	27.5,
+	29.13523509488062,
+	30.86770632850775,
+	32.70319566257483,
+	34.64782887210901,
+	36.70809598967594,
+	38.890872965260115,
+	41.20344461410875,
+	43.653528929125486,
+	46.24930283895431,
+	48.99942949771867,
+	51.91308719749314,
+	55,
+	58.27047018976124,
+	61.7354126570155,
+	65.40639132514966,
+	69.29565774421802,
+	73.41619197935188,
+	77.78174593052023,
+	82.4068892282175,
+	87.30705785825097,
+	92.49860567790861,
+	97.99885899543735,
+	103.82617439498628,
+	110,
+	116.54094037952248,
+	123.47082531403103,
+	130.8127826502993,
+	138.59131548843604,
+	146.83238395870382,
+	155.56349186104046,
+	164.81377845643496,
+	174.61411571650197,
+	184.99721135581723,
+	195.99771799087463,
+	207.65234878997256,
+	220,
+	233.08188075904496,
+	246.941650628062,
+	261.6255653005986,
+	277.1826309768721,
+	293.6647679174076,
+	311.12698372208087,
+	329.6275569128699,
+	349.22823143300394,
+	369.99442271163446,
+	391.99543598174927,
+	415.3046975799451,
+	440,
+	466.1637615180899,
+	493.8833012561241,
+	523.2511306011972,
+	554.3652619537442,
+	587.3295358348151,
+	622.2539674441618,
+	659.2551138257398,
+	698.4564628660078,
+	739.9888454232688,
+	783.9908719634986,
+	830.6093951598903,
+	880,
+	932.3275230361799,
+	987.7666025122483,
+	1046.5022612023945,
+	1108.7305239074883,
+	1174.6590716696303,
+	1244.5079348883235,
+	1318.5102276514795,
+	1396.9129257320155,
+	1479.9776908465376,
+	1567.981743926997,
+	1661.2187903197805,
+	1760,
+	1864.6550460723597,
+	1975.533205024496,
+	2093.004522404789,
+	2217.4610478149766,
+	2349.31814333926,
+	2489.015869776647,
+	2637.02045530296,
+	2793.825851464031,
+	2959.955381693075,
+	3135.9634878539946,
+	3322.437580639561,
+	3520,
+	3729.3100921447194,
+	3951.066410048992,
+	4186.009044809578,
+	4434.922095629953,
+	4698.63628667852,
+	4978.031739553294,
+	5274.04091060592,
+	5587.651702928062,
+	5919.91076338615,
+	6271.926975707989,
+	6644.875161279122,
+	7040,
+	7458.620184289437,
+	7902.132820097988,
+	8372.018089619156,
+	8869.844191259906,
+	9397.272573357044,
+	9956.063479106588,
+	10548.081821211836,
+	11175.303405856126,
+	11839.8215267723,
+	12543.853951415975,
+}
+
+var noteLabels = [128]string{
+	"C-1",
+	"C#-1",
+	"D-1",
+	"D#-1",
+	"E-1",
+	"F-1",
+	"F#-1",
+	"G-1",
+	"G#-1",
+	"A-1",
+	"A#-1",
+	"B-1",
+	"C0",
+	"C#0",
+	"D0",
+	"D#0",
+	"E0",
+	"F0",
+	"F#0",
+	"G0",
+	"G#0",
+	"A0",
+	"A#0",
+	"B0",
+	"C1",
+	"C#1",
+	"D1",
+	"D#1",
+	"E1",
+	"F1",
+	"F#1",
+	"G1",
+	"G#1",
+	"A1",
+	"A#1",
+	"B1",
+	"C2",
+	"C#2",
+	"D2",
+	"D#2",
+	"E2",
+	"F2",
+	"F#2",
+	"G2",
+	"G#2",
+	"A2",
+	"A#2",
+	"B2",
+	"C3",
+	"C#3",
+	"D3",
+	"D#3",
+	"E3",
+	"F3",
+	"F#3",
+	"G3",
+	"G#3",
+	"A3",
+	"A#3",
+	"B3",
+	"C4",
+	"C#4",
+	"D4",
+	"D#4",
+	"E4",
+	"F4",
+	"F#4",
+	"G4",
+	"G#4",
+	"A4",
+	"A#4",
+	"B4",
+	"C5",
+	"C#5",
+	"D5",
+	"D#5",
+	"E5",
+	"F5",
+	"F#5",
+	"G5",
+	"G#5",
+	"A5",
+	"A#5",
+	"B5",
+	"C6",
+	"C#6",
+	"D6",
+	"D#6",
+	"E6",
+	"F6",
+	"F#6",
+	"G6",
+	"G#6",
+	"A6",
+	"A#6",
+	"B6",
+	"C7",
+	"C#7",
+	"D7",
+	"D#7",
+	"E7",
+	"F7",
+	"F#7",
+	"G7",
+	"G#7",
+	"A7",
+	"A#7",
+	"B7",
+	"C8",
+	"C#8",
+	"D8",
+	"D#8",
+	"E8",
+	"F8",
+	"F#8",
+	"G8",
+	"G#8",
+	"A8",
+	"A#8",
+	"B8",
+	"C9",
+	"C#9",
+	"D9",
+	"D#9",
+	"E9",
+	"F9",
+	"F#9",
+	"G9",
+}
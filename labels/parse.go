@@ -0,0 +1,123 @@
+package labels
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Pitch is a spelled note, like C#4 (as opposed to its index, where C#4 and
+// Db4 are the same)
+type Pitch struct {
+	// Letter is 0 for C, 1 for D, ..., 6 for B
+	Letter int
+	// Accidental is in semitones: -2 is a double flat, 2 a double sharp
+	Accidental int
+	Octave     int
+}
+
+const letterNames = "CDEFGAB"
+
+// letterTones is the number of semitones between C and each letter
+var letterTones = [7]int{0, 2, 4, 5, 7, 9, 11}
+
+// Index returns the index of the key (like FromIndex). B#, Cb, E# and Fb
+// just work: B#3 is C4 and Cb4 is B3, because the octave number goes with
+// the letter.
+func (p Pitch) Index() int {
+	return p.Octave*12 + letterTones[p.Letter] + p.Accidental - 8
+}
+
+// accidentals maps every symbol to how many semitones it moves the letter
+var accidentals = map[rune]int{
+	'#': 1,
+	'b': -1,
+	'x': 2,
+	'♯': 1,
+	'♭': -1,
+	'𝄪': 2,
+	'𝄫': -2,
+}
+
+// parseScientific parses a name in scientific pitch notation: a letter (any
+// case), up to a double accidental (#, b, x, or the unicode ones), and an
+// octave (any integer, like C-1 or C10). Errors say at which byte the problem
+// is.
+func parseScientific(name string) (p Pitch, err error) {
+	pos := 0
+	if pos >= len(name) {
+		return p, parseError(name, pos, "expected a letter from A to G")
+	}
+	var ok bool
+	p.Letter, ok = parseLetter(name[pos])
+	if !ok {
+		return p, parseError(name, pos, "expected a letter from A to G")
+	}
+	pos++
+
+	p.Accidental, pos, err = parseAccidentals(name, pos)
+	if err != nil {
+		return p, err
+	}
+
+	p.Octave, err = parseOctave(name, pos)
+	return p, err
+}
+
+func parseLetter(c byte) (int, bool) {
+	if c >= 'a' && c <= 'g' {
+		c -= 'a' - 'A'
+	}
+	for i := 0; i < len(letterNames); i++ {
+		if letterNames[i] == c {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// parseAccidentals reads the accidentals starting at pos, and returns the
+// total and where they stop
+func parseAccidentals(name string, pos int) (total int, end int, err error) {
+	for pos < len(name) {
+		r, size := utf8.DecodeRuneInString(name[pos:])
+		semitones, ok := accidentals[r]
+		if !ok {
+			break
+		}
+		if total != 0 && (total > 0) != (semitones > 0) {
+			return 0, pos, parseError(name, pos, "can't mix sharps and flats")
+		}
+		total += semitones
+		if total > 2 || total < -2 {
+			return 0, pos, parseError(name, pos, "more than a double accidental")
+		}
+		pos += size
+	}
+	return total, pos, nil
+}
+
+// parseOctave reads the rest of the name as an integer
+func parseOctave(name string, pos int) (int, error) {
+	start := pos
+	if pos < len(name) && name[pos] == '-' {
+		pos++
+	}
+	if pos >= len(name) {
+		return 0, parseError(name, pos, "expected an octave number")
+	}
+	for i := pos; i < len(name); i++ {
+		if name[i] < '0' || name[i] > '9' {
+			return 0, parseError(name, i, "expected a digit")
+		}
+	}
+	octave, err := strconv.Atoi(name[start:])
+	if err != nil {
+		return 0, parseError(name, start, "octave out of range")
+	}
+	return octave, nil
+}
+
+func parseError(name string, pos int, msg string) error {
+	return fmt.Errorf("%q at byte %d: %s: %w", name, pos, msg, ErrParsingName)
+}
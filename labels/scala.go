@@ -0,0 +1,311 @@
+package labels
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrParsingScala = errors.New("parsing scala file")
+
+// ScalaScale is a Scala scale (.scl file, see
+// http://www.huygens-fokker.org/scala/scl_format.html)
+type ScalaScale struct {
+	Description string
+	// Cents are the distances from the first degree (which is always 0, so
+	// it's not in there) to every other one. The last one is the period
+	// (usually the octave, 1200)
+	Cents []float64
+}
+
+// ParseScalaScale reads a .scl file. Pitches can be in cents (they have a
+// '.') or ratios (like 3/2 or 2).
+func ParseScalaScale(r io.Reader) (*ScalaScale, error) {
+	lines := newScalaLines(r)
+
+	description, ok := lines.next()
+	if !ok {
+		return nil, lines.missing("description")
+	}
+	line, ok := lines.next()
+	if !ok {
+		return nil, lines.missing("number of notes")
+	}
+	count, err := strconv.Atoi(firstField(line))
+	if err != nil || count < 1 {
+		return nil, lines.errorf("invalid number of notes %q", line)
+	}
+
+	scale := &ScalaScale{Description: strings.TrimSpace(description)}
+	for i := 0; i < count; i++ {
+		line, ok := lines.next()
+		if !ok {
+			return nil, lines.missing(fmt.Sprintf("pitch #%d (expected %d)", i+1, count))
+		}
+		cents, err := parsePitch(firstField(line))
+		if err != nil {
+			return nil, lines.errorf("%s", err)
+		}
+		scale.Cents = append(scale.Cents, cents)
+	}
+	if err := lines.err(); err != nil {
+		return nil, err
+	}
+	if scale.Cents[count-1] <= 0 {
+		return nil, fmt.Errorf("period of %f cents (needs to go up): %w", scale.Cents[count-1], ErrParsingScala)
+	}
+	return scale, nil
+}
+
+// parsePitch returns the pitch in cents
+func parsePitch(s string) (float64, error) {
+	if strings.Contains(s, ".") {
+		cents, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cents %q", s)
+		}
+		return cents, nil
+	}
+
+	num, den := s, "1"
+	if i := strings.IndexByte(s, '/'); i != -1 {
+		num, den = s[:i], s[i+1:]
+	}
+	n, err := strconv.ParseUint(num, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ratio %q", s)
+	}
+	d, err := strconv.ParseUint(den, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ratio %q", s)
+	}
+	if n == 0 || d == 0 {
+		return 0, fmt.Errorf("invalid ratio %q (needs to be positive)", s)
+	}
+	return ratioToCents(float64(n) / float64(d)), nil
+}
+
+// ratio returns the ratio between the degree and the first one
+func (s *ScalaScale) ratio(degree int) float64 {
+	n := len(s.Cents)
+	i := mod(degree, n)
+	periods := (degree - i) / n
+	cents := float64(periods) * s.Cents[n-1]
+	if i > 0 {
+		cents += s.Cents[i-1]
+	}
+	return math.Pow(2, cents/1200)
+}
+
+// KeyboardMapping is a Scala keyboard mapping (.kbm file, see
+// http://www.huygens-fokker.org/scala/help.htm#mappings). It says which scale
+// degree every MIDI key plays.
+type KeyboardMapping struct {
+	// Size is the number of keys in the pattern which gets repeated. 0 means
+	// linear: every key is the next degree
+	Size int
+	// First and Last are the range of MIDI keys which get mapped
+	First, Last int
+	// Middle is the MIDI key playing the first degree of the scale
+	Middle int
+	// Reference is the MIDI key which plays at Frequency
+	Reference int
+	Frequency float64
+	// OctaveDegree is the degree reached after going through the whole
+	// pattern once (only used if Size isn't 0)
+	OctaveDegree int
+	// Degrees is the pattern, starting at Middle. -1 means the key isn't
+	// mapped (an 'x' in the file)
+	Degrees []int
+}
+
+// DefaultKeyboardMapping maps the scale linearly, with the first degree on
+// middle C, and A4 at 440 Hz
+var DefaultKeyboardMapping = KeyboardMapping{
+	First:     0,
+	Last:      127,
+	Middle:    60,
+	Reference: 69,
+	Frequency: 440,
+}
+
+// ParseKeyboardMapping reads a .kbm file
+func ParseKeyboardMapping(r io.Reader) (*KeyboardMapping, error) {
+	lines := newScalaLines(r)
+
+	m := &KeyboardMapping{}
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{"map size", &m.Size},
+		{"first key", &m.First},
+		{"last key", &m.Last},
+		{"middle key", &m.Middle},
+		{"reference key", &m.Reference},
+	}
+	for _, field := range ints {
+		line, ok := lines.next()
+		if !ok {
+			return nil, lines.missing(field.name)
+		}
+		v, err := strconv.Atoi(firstField(line))
+		if err != nil {
+			return nil, lines.errorf("invalid %s %q", field.name, line)
+		}
+		*field.dst = v
+	}
+
+	line, ok := lines.next()
+	if !ok {
+		return nil, lines.missing("reference frequency")
+	}
+	freq, err := strconv.ParseFloat(firstField(line), 64)
+	if err != nil || !(freq > 0) {
+		return nil, lines.errorf("invalid reference frequency %q", line)
+	}
+	m.Frequency = freq
+
+	line, ok = lines.next()
+	if !ok {
+		return nil, lines.missing("octave degree")
+	}
+	m.OctaveDegree, err = strconv.Atoi(firstField(line))
+	if err != nil {
+		return nil, lines.errorf("invalid octave degree %q", line)
+	}
+
+	if m.Size < 0 {
+		return nil, fmt.Errorf("negative map size %d: %w", m.Size, ErrParsingScala)
+	}
+	// if there are fewer lines than the size, the rest isn't mapped
+	for i := 0; i < m.Size; i++ {
+		line, ok := lines.next()
+		if !ok {
+			m.Degrees = append(m.Degrees, -1)
+			continue
+		}
+		field := firstField(line)
+		if field == "x" || field == "X" {
+			m.Degrees = append(m.Degrees, -1)
+			continue
+		}
+		degree, err := strconv.Atoi(field)
+		if err != nil || degree < 0 {
+			return nil, lines.errorf("invalid degree %q", line)
+		}
+		m.Degrees = append(m.Degrees, degree)
+	}
+	if err := lines.err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// degree returns the degree of the scale played by the MIDI key, and false if
+// the key isn't mapped
+func (m *KeyboardMapping) degree(key int) (int, bool) {
+	if key < m.First || key > m.Last {
+		return 0, false
+	}
+	if m.Size == 0 {
+		return key - m.Middle, true
+	}
+	i := mod(key-m.Middle, m.Size)
+	patterns := (key - m.Middle - i) / m.Size
+	if m.Degrees[i] < 0 {
+		return 0, false
+	}
+	return patterns*m.OctaveDegree + m.Degrees[i], true
+}
+
+// ScalaTuning tunes the keys with a Scala scale and keyboard mapping.
+type ScalaTuning struct {
+	scale   *ScalaScale
+	mapping *KeyboardMapping
+	// ratio of the reference key
+	reference float64
+}
+
+// NewScalaTuning returns a tuning (to give to NewLabels). A nil mapping
+// means DefaultKeyboardMapping.
+func NewScalaTuning(scale *ScalaScale, mapping *KeyboardMapping) (*ScalaTuning, error) {
+	if mapping == nil {
+		m := DefaultKeyboardMapping
+		mapping = &m
+	}
+	degree, ok := mapping.degree(mapping.Reference)
+	if !ok {
+		return nil, fmt.Errorf("reference key %d isn't mapped: %w", mapping.Reference, ErrParsingScala)
+	}
+	return &ScalaTuning{
+		scale:     scale,
+		mapping:   mapping,
+		reference: scale.ratio(degree),
+	}, nil
+}
+
+// Frequency returns 0 for keys which aren't mapped
+func (t *ScalaTuning) Frequency(index int) float64 {
+	degree, ok := t.mapping.degree(index + midiOffset)
+	if !ok {
+		return 0
+	}
+	return t.mapping.Frequency * t.scale.ratio(degree) / t.reference
+}
+
+// scalaLines reads the lines of a scala file, skipping the comments
+type scalaLines struct {
+	scanner *bufio.Scanner
+	number  int
+}
+
+func newScalaLines(r io.Reader) *scalaLines {
+	return &scalaLines{scanner: bufio.NewScanner(r)}
+}
+
+// next returns the next line which isn't a comment. Empty lines are returned
+// (the description of a scale can be empty)
+func (l *scalaLines) next() (string, bool) {
+	for l.scanner.Scan() {
+		l.number++
+		line := strings.TrimRight(l.scanner.Text(), "\r")
+		if strings.HasPrefix(line, "!") {
+			continue
+		}
+		return line, true
+	}
+	return "", false
+}
+
+func (l *scalaLines) err() error {
+	if err := l.scanner.Err(); err != nil {
+		return fmt.Errorf("line %d: %s (%w)", l.number, err, ErrParsingScala)
+	}
+	return nil
+}
+
+func (l *scalaLines) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("line %d: %s: %w", l.number, fmt.Sprintf(format, a...), ErrParsingScala)
+}
+
+func (l *scalaLines) missing(what string) error {
+	if err := l.err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("missing %s: %w", what, ErrParsingScala)
+}
+
+// firstField returns the first word of the line. Everything after it is
+// ignored (it's usually a comment)
+func firstField(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
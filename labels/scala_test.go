@@ -0,0 +1,209 @@
+package labels
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+const pentatonic = `! slendro.scl
+!
+Just pentatonic, with a comment after the ratio
+ 5
+!
+ 9/8   major whole tone
+ 5/4
+ 3/2
+ 5/3
+ 2
+`
+
+// 19 equal divisions of the octave, in cents
+const edo19 = `! 19-edo.scl
+19-EDO
+19
+!
+63.15789
+126.31579
+189.47368
+252.63158
+315.78947
+378.94737
+442.10526
+505.26316
+568.42105
+631.57895
+694.73684
+757.89474
+821.05263
+884.21053
+947.36842
+1010.52632
+1073.68421
+1136.84211
+1200.0
+`
+
+func TestParseScale(t *testing.T) {
+	scale, err := ParseScalaScale(strings.NewReader(pentatonic))
+	if err != nil {
+		t.Fatalf("parsing: %s", err)
+	}
+	if scale.Description != "Just pentatonic, with a comment after the ratio" {
+		t.Errorf("description, actual: %q", scale.Description)
+	}
+	expected := []float64{203.91, 386.31, 701.96, 884.36, 1200}
+	if len(scale.Cents) != len(expected) {
+		t.Fatalf("cents, actual: %v, expected: %v", scale.Cents, expected)
+	}
+	for i := range expected {
+		if roundTo(scale.Cents[i], 2) != expected[i] {
+			t.Errorf("cents #%d, actual: %f, expected: %f", i, scale.Cents[i], expected[i])
+		}
+	}
+}
+
+func TestParseScaleErrors(t *testing.T) {
+	var rows = []struct {
+		name, file string
+	}{
+		{"empty", ""},
+		{"no count", "description\n"},
+		{"invalid count", "description\nfive\n"},
+		{"not enough pitches", "description\n3\n100.0\n200.0\n"},
+		{"invalid cents", "description\n1\n12.x\n"},
+		{"zero denominator", "description\n1\n3/0\n"},
+		{"negative ratio", "description\n1\n-3/2\n"},
+		{"period going down", "description\n1\n-100.0\n"},
+	}
+	for _, row := range rows {
+		_, err := ParseScalaScale(strings.NewReader(row.file))
+		if !errors.Is(err, ErrParsingScala) {
+			t.Errorf("%s, actual: %v, expected: %v", row.name, err, ErrParsingScala)
+		}
+	}
+}
+
+func TestScalaTuningDefaultMapping(t *testing.T) {
+	scale, err := ParseScalaScale(strings.NewReader(edo19))
+	if err != nil {
+		t.Fatalf("parsing: %s", err)
+	}
+	tuning, err := NewScalaTuning(scale, nil)
+	if err != nil {
+		t.Fatalf("creating tuning: %s", err)
+	}
+	labels := NewLabels(tuning)
+
+	// A4 is still 440, and every key is 1/19 of an octave apart, so 19 keys
+	// up is an octave
+	if actual := labels.F("A4"); math.Abs(actual-440) > 1e-9 {
+		t.Errorf("A4, actual: %f, expected: %f", actual, 440.0)
+	}
+	if actual := labels.FromIndex(49 + 19); math.Abs(actual-880) > 1e-3 {
+		t.Errorf("19 keys above A4, actual: %f, expected: %f", actual, 880.0)
+	}
+	ratio := labels.FromIndex(50) / labels.FromIndex(49)
+	if math.Abs(ratio-math.Pow(2, 1.0/19)) > 1e-6 {
+		t.Errorf("step, actual: %f, expected: %f", ratio, math.Pow(2, 1.0/19))
+	}
+}
+
+func TestScalaTuningKeyboardMapping(t *testing.T) {
+	// the pentatonic scale on the white keys only, from C4 (60), with E4 (64)
+	// at 330 Hz
+	kbm := `! white.kbm
+12
+0
+127
+60
+64
+330.0
+5
+! C C# D D# E F F# G G# A A# B
+0
+x
+1
+x
+2
+x
+x
+3
+x
+4
+x
+`
+	mapping, err := ParseKeyboardMapping(strings.NewReader(kbm))
+	if err != nil {
+		t.Fatalf("parsing mapping: %s", err)
+	}
+	// the last line is missing, so B isn't mapped either
+	if len(mapping.Degrees) != 12 || mapping.Degrees[11] != -1 {
+		t.Fatalf("degrees: %v", mapping.Degrees)
+	}
+	scale, err := ParseScalaScale(strings.NewReader(pentatonic))
+	if err != nil {
+		t.Fatalf("parsing scale: %s", err)
+	}
+	tuning, err := NewScalaTuning(scale, mapping)
+	if err != nil {
+		t.Fatalf("creating tuning: %s", err)
+	}
+
+	labels := NewLabels(tuning)
+	var rows = []struct {
+		name string
+		freq float64
+	}{
+		// E4 is the third degree (5/4)
+		{"E4", 330},
+		{"C4", 264},
+		{"D4", 297},
+		{"G4", 396},
+		{"A4", 440},
+		{"C5", 528},
+		{"C3", 132},
+		{"A3", 220},
+		// unmapped
+		{"C#4", 0},
+		{"B4", 0},
+	}
+	for _, row := range rows {
+		actual, err := labels.Frequency(row.name)
+		if err != nil {
+			t.Fatalf("name: %q, err: %s", row.name, err)
+		}
+		if math.Abs(actual-row.freq) > 1e-9 {
+			t.Errorf("name: %q, actual: %f, expected: %f", row.name, actual, row.freq)
+		}
+	}
+}
+
+func TestParseKeyboardMappingErrors(t *testing.T) {
+	var rows = []struct {
+		name, file string
+	}{
+		{"empty", ""},
+		{"invalid size", "twelve\n"},
+		{"missing frequency", "0\n0\n127\n60\n69\n"},
+		{"invalid frequency", "0\n0\n127\n60\n69\n-440\n12\n"},
+		{"invalid degree", "1\n0\n127\n60\n69\n440\n12\ny\n"},
+	}
+	for _, row := range rows {
+		_, err := ParseKeyboardMapping(strings.NewReader(row.file))
+		if !errors.Is(err, ErrParsingScala) {
+			t.Errorf("%s, actual: %v, expected: %v", row.name, err, ErrParsingScala)
+		}
+	}
+
+	// the reference key has to be mapped
+	mapping, err := ParseKeyboardMapping(strings.NewReader("2\n0\n127\n60\n61\n440\n1\n0\nx\n"))
+	if err != nil {
+		t.Fatalf("parsing mapping: %s", err)
+	}
+	scale, _ := ParseScalaScale(strings.NewReader(pentatonic))
+	if _, err := NewScalaTuning(scale, mapping); !errors.Is(err, ErrParsingScala) {
+		t.Errorf("unmapped reference, actual: %v, expected: %v", err, ErrParsingScala)
+	}
+}
@@ -0,0 +1,75 @@
+package labels
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrUnknownMode = errors.New("unknown mode")
+
+// Mode is the kind of scale, like major or dorian
+type Mode string
+
+const (
+	ModeMajor           Mode = "major"
+	ModeMinor           Mode = "minor"
+	ModeHarmonicMinor   Mode = "harmonic minor"
+	ModeMelodicMinor    Mode = "melodic minor"
+	ModeIonian          Mode = "ionian"
+	ModeDorian          Mode = "dorian"
+	ModePhrygian        Mode = "phrygian"
+	ModeLydian          Mode = "lydian"
+	ModeMixolydian      Mode = "mixolydian"
+	ModeAeolian         Mode = "aeolian"
+	ModeLocrian         Mode = "locrian"
+	ModeMajorPentatonic Mode = "major pentatonic"
+	ModeMinorPentatonic Mode = "minor pentatonic"
+	ModeBlues           Mode = "blues"
+)
+
+// the intervals from the root to each degree
+var modes = map[Mode]string{
+	ModeMajor:           "P1 M2 M3 P4 P5 M6 M7",
+	ModeMinor:           "P1 M2 m3 P4 P5 m6 m7",
+	ModeHarmonicMinor:   "P1 M2 m3 P4 P5 m6 M7",
+	ModeMelodicMinor:    "P1 M2 m3 P4 P5 M6 M7",
+	ModeIonian:          "P1 M2 M3 P4 P5 M6 M7",
+	ModeDorian:          "P1 M2 m3 P4 P5 M6 m7",
+	ModePhrygian:        "P1 m2 m3 P4 P5 m6 m7",
+	ModeLydian:          "P1 M2 M3 A4 P5 M6 M7",
+	ModeMixolydian:      "P1 M2 M3 P4 P5 M6 m7",
+	ModeAeolian:         "P1 M2 m3 P4 P5 m6 m7",
+	ModeLocrian:         "P1 m2 m3 P4 d5 m6 m7",
+	ModeMajorPentatonic: "P1 M2 M3 P5 M6",
+	ModeMinorPentatonic: "P1 m3 P4 P5 m7",
+	ModeBlues:           "P1 m3 P4 d5 P5 m7",
+}
+
+// Scale returns the notes of the scale going up from root, for one octave
+// (the root isn't repeated at the top). The notes are spelled with one letter
+// per degree, so D minor has a Bb, not an A#.
+func Scale(root Pitch, mode Mode) ([]Pitch, error) {
+	if mode == "" {
+		mode = ModeMajor
+	}
+	d, ok := modes[mode]
+	if !ok {
+		return nil, fmt.Errorf("%q: %w", mode, ErrUnknownMode)
+	}
+	var pitches []Pitch
+	for _, i := range intervals(d) {
+		pitches = append(pitches, root.Transpose(i))
+	}
+	return pitches, nil
+}
+
+// intervals parses a list of intervals, like "P1 m3 P5". The tables are
+// static, so it panics if there's an error
+func intervals(s string) []Interval {
+	var intervals []Interval
+	for _, field := range strings.Fields(s) {
+		intervals = append(intervals, MustParseInterval(field))
+	}
+	return intervals
+}
@@ -0,0 +1,125 @@
+package labels
+
+import (
+	"math"
+)
+
+// Tuning gives the frequency of every key, by index (A0 is 1, A4 is 49, like
+// FromIndex).
+//
+// Labels caches frequencies per tuning, using it as a map key, so it has to be
+// comparable (use a pointer if it isn't). Frequency can be called from several
+// goroutines at once.
+type Tuning interface {
+	Frequency(index int) float64
+}
+
+// Standard is 12-TET with A4 at 440 Hz
+var Standard Tuning = EqualTemperament(440)
+
+// Temperament tunes the 12 tones of an octave relative to a tonic. Every
+// octave is the same, and A4 is always exactly at concert pitch.
+type Temperament struct {
+	// A4 is the concert pitch, in Hz
+	A4 float64
+	// Tonic is the tone Cents are relative to (0 is C, 1 is C#, ..., 11 is B)
+	Tonic int
+	// Cents are the distances from the tonic to each of the 12 tones above
+	// it (starting with the tonic itself, so Cents[0] is 0)
+	Cents [12]float64
+}
+
+func (t Temperament) Frequency(index int) float64 {
+	return t.A4 * math.Pow(2, (t.cents(index)-t.cents(49))/1200)
+}
+
+// cents returns the distance between the tonic in octave 0 and the key at
+// index
+func (t Temperament) cents(index int) float64 {
+	// index 40 is C4, shift it so that the tonic of octave 0 is at 0
+	i := index + 8 - t.Tonic
+	degree := mod(i, 12)
+	octave := (i - degree) / 12
+	return 1200*float64(octave) + t.Cents[degree]
+}
+
+// EqualTemperament divides the octave in 12 equal semitones
+func EqualTemperament(a4 float64) Temperament {
+	t := Temperament{A4: a4}
+	for i := range t.Cents {
+		t.Cents[i] = 100 * float64(i)
+	}
+	return t
+}
+
+// justRatios are the 5-limit just intervals from the tonic
+var justRatios = [12]float64{
+	1, 16.0 / 15, 9.0 / 8, 6.0 / 5, 5.0 / 4, 4.0 / 3,
+	45.0 / 32, 3.0 / 2, 8.0 / 5, 5.0 / 3, 9.0 / 5, 15.0 / 8,
+}
+
+// Just is 5-limit just intonation relative to the tonic (0 is C, ..., 11 is
+// B). It only sounds good in the keys close to the tonic.
+func Just(a4 float64, tonic int) Temperament {
+	t := Temperament{A4: a4, Tonic: mod(tonic, 12)}
+	for i, ratio := range justRatios {
+		t.Cents[i] = ratioToCents(ratio)
+	}
+	return t
+}
+
+// Pythagorean stacks pure fifths (3/2) from the tonic: 6 up and 5 down, so the
+// wolf fifth is between the augmented fourth and the minor second.
+func Pythagorean(a4 float64, tonic int) Temperament {
+	return fifths(a4, tonic, ratioToCents(3.0/2), -5)
+}
+
+// QuarterCommaMeantone stacks fifths narrowed by a quarter of a syntonic
+// comma, so that the major thirds are pure (5/4): 8 up and 3 down (from Eb to
+// G# when the tonic is C).
+func QuarterCommaMeantone(a4 float64, tonic int) Temperament {
+	return fifths(a4, tonic, ratioToCents(5)/4, -3)
+}
+
+// fifths builds a temperament by stacking 12 times the same fifth (in cents),
+// starting from lowest fifths below the tonic.
+func fifths(a4 float64, tonic int, fifth float64, lowest int) Temperament {
+	t := Temperament{A4: a4, Tonic: mod(tonic, 12)}
+	for n := lowest; n < lowest+12; n++ {
+		degree := mod(7*n, 12)
+		cents := float64(n) * fifth
+		// bring it back in the first octave
+		t.Cents[degree] = cents - 1200*math.Floor(cents/1200)
+	}
+	return t
+}
+
+// WerckmeisterIII is Andreas Werckmeister's well temperament (1691): C-G,
+// G-D, D-A and B-F# are narrowed by a quarter of a Pythagorean comma, the
+// other fifths are pure.
+func WerckmeisterIII(a4 float64) Temperament {
+	return Temperament{
+		A4: a4,
+		Cents: [12]float64{
+			0, 90.225, 192.180, 294.135, 390.225, 498.045,
+			588.270, 696.090, 792.180, 888.270, 996.090, 1092.180,
+		},
+	}
+}
+
+// KirnbergerIII is Johann Philipp Kirnberger's well temperament (1779): the
+// fifths C-G, G-D, D-A and A-E are narrowed by a quarter of a syntonic comma,
+// F#-C# by a schisma, and the other fifths are pure.
+func KirnbergerIII(a4 float64) Temperament {
+	return Temperament{
+		A4: a4,
+		Cents: [12]float64{
+			0, 90.225, 193.157, 294.135, 386.314, 498.045,
+			590.224, 696.578, 792.180, 889.735, 996.090, 1088.269,
+		},
+	}
+}
+
+func ratioToCents(ratio float64) float64 {
+	return 1200 * math.Log2(ratio)
+}
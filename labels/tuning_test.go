@@ -0,0 +1,71 @@
+package labels
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTuningIntervals(t *testing.T) {
+	var rows = []struct {
+		name   string
+		tuning Tuning
+		// the ratio between two keys, by index
+		from, to int
+		ratio    float64
+	}{
+		{"12-TET octave", EqualTemperament(415), 40, 52, 2},
+		{"just major third", Just(440, 0), 40, 44, 5.0 / 4},
+		{"just fifth", Just(440, 0), 40, 47, 3.0 / 2},
+		{"just fifth from D", Just(440, 2), 42, 49, 3.0 / 2},
+		{"pythagorean fifth", Pythagorean(440, 0), 40, 47, 3.0 / 2},
+		{"pythagorean major third", Pythagorean(440, 0), 40, 44, 81.0 / 64},
+		{"meantone major third", QuarterCommaMeantone(440, 0), 40, 44, 5.0 / 4},
+		{"meantone octave", QuarterCommaMeantone(440, 0), 13, 25, 2},
+		{"werckmeister pure fifth (E-B)", WerckmeisterIII(440), 44, 51, 3.0 / 2},
+		{"kirnberger pure major third", KirnbergerIII(440), 40, 44, 5.0 / 4},
+	}
+	for _, row := range rows {
+		actual := row.tuning.Frequency(row.to) / row.tuning.Frequency(row.from)
+		if math.Abs(actual-row.ratio) > 1e-4 {
+			t.Errorf("%s, actual: %f, expected: %f", row.name, actual, row.ratio)
+		}
+	}
+}
+
+func TestTuningConcertPitch(t *testing.T) {
+	for _, a4 := range []float64{415, 432, 440} {
+		for _, tuning := range []Tuning{
+			EqualTemperament(a4), Just(a4, 0), Just(a4, 7), Pythagorean(a4, 3),
+			QuarterCommaMeantone(a4, 0), WerckmeisterIII(a4), KirnbergerIII(a4),
+		} {
+			if actual := tuning.Frequency(49); math.Abs(actual-a4) > 1e-9 {
+				t.Errorf("A4 in %v, actual: %f, expected: %f", tuning, actual, a4)
+			}
+		}
+	}
+}
+
+func TestLabelsSetTuning(t *testing.T) {
+	labels := NewLabels(EqualTemperament(415))
+	if actual := labels.F("A4"); actual != 415 {
+		t.Errorf("A4 at 415, actual: %f, expected: %f", actual, 415.0)
+	}
+
+	// a whole semitone down from 440, but it's still an A4
+	label, index, cents, err := labels.Label(415, Sharps)
+	if err != nil || label != "A4" || index != 49 || math.Abs(cents) > 1e-9 {
+		t.Errorf("label of 415, actual: %s %d %f %v, expected: A4 49 0 <nil>", label, index, cents, err)
+	}
+
+	labels.SetTuning(nil)
+	if actual := labels.F("A4"); actual != 440 {
+		t.Errorf("A4 after switching back to standard, actual: %f, expected: %f", actual, 440.0)
+	}
+	labels.SetTuning(EqualTemperament(415))
+	if actual := labels.F("A4"); actual != 415 {
+		t.Errorf("A4 at 415 again, actual: %f, expected: %f", actual, 415.0)
+	}
+	if len(labels.freqs) != 2 {
+		t.Errorf("cached tunings, actual: %d, expected: %d", len(labels.freqs), 2)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -14,44 +15,64 @@ import (
 	"github.com/faiface/beep/speaker"
 	"github.com/math2001/piano/frac"
 	"github.com/math2001/piano/labels"
+	"github.com/math2001/piano/midi"
 	"github.com/math2001/piano/piece"
+	"github.com/math2001/piano/wav"
 	"github.com/math2001/piano/wave"
 )
 
 func main() {
-	lb := labels.NewLabels()
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		if err := render(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := exportMIDI(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := importMIDI(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	p := &piece.Piece{
 		Notes: []piece.Note{
 			// C5:  **
 			// A4: *  * ****
 			// F4:     *****
 			piece.Note{
-				Frequency: lb.F("A4"),
+				Frequency: labels.A4.Frequency(),
 				Duration:  frac.F(1, 2),
 				Start:     frac.F(0, 2),
 			},
 			piece.Note{
-				Frequency: lb.F("C5"),
+				Frequency: labels.C5.Frequency(),
 				Duration:  frac.F(2, 2),
 				Start:     frac.F(1, 2),
 			},
 			piece.Note{
-				Frequency: lb.F("A4"),
+				Frequency: labels.A4.Frequency(),
 				Duration:  frac.F(1, 2),
 				Start:     frac.F(3, 2),
 			},
 			piece.Note{
-				Frequency: lb.F("F4"),
+				Frequency: labels.F4.Frequency(),
 				Duration:  frac.F(1, 2),
 				Start:     frac.F(4, 2),
 			},
 			piece.Note{
-				Frequency: lb.F("F4"),
+				Frequency: labels.F4.Frequency(),
 				Duration:  frac.F(4, 2),
 				Start:     frac.F(5, 2),
 			},
 			piece.Note{
-				Frequency: lb.F("A4"),
+				Frequency: labels.A4.Frequency(),
 				Duration:  frac.F(4, 2),
 				Start:     frac.F(5, 2),
 			},
@@ -73,9 +94,13 @@ func main() {
 	sr := beep.SampleRate(44100)
 	speaker.Init(sr, sr.N(time.Second/6))
 
+	pieceStreamer, err := p.GetStreamer(sr, piece.FromBPM(60))
+	if err != nil {
+		log.Fatalf("getting streamer: %s", err)
+	}
 	// put it in a gain, just so we don't play full throttle
 	streamer := &effects.Gain{
-		Streamer: p.GetStreamer(sr, piece.FromBPM(60)),
+		Streamer: pieceStreamer,
 		Gain:     -0.1,
 	}
 
@@ -86,15 +111,130 @@ func main() {
 	<-done
 }
 
+// render reads a piece from a JSON file and writes it to a WAV file
+//
+//	piano render [flags] piece.json
+func render(args []string) error {
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	output := flags.String("o", "out.wav", "the WAV file to write")
+	bpm := flags.Int("bpm", 60, "beats per minute")
+	rate := flags.Int("rate", 44100, "sample rate")
+	bits := flags.Int("bits", 16, "16 or 24 (PCM), or 32 (float)")
+	mono := flags.Bool("mono", false, "write a single channel instead of two")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: piano render [flags] piece.json")
+	}
+
+	format := wav.Format{Channels: 2}
+	if *mono {
+		format.Channels = 1
+	}
+	switch *bits {
+	case 16:
+		format.Encoding = wav.PCM16
+	case 24:
+		format.Encoding = wav.PCM24
+	case 32:
+		format.Encoding = wav.Float32
+	default:
+		return fmt.Errorf("invalid number of bits %d (need 16, 24 or 32)", *bits)
+	}
+
+	in, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("opening piece: %s", err)
+	}
+	defer in.Close()
+	p := &piece.Piece{}
+	if err := json.NewDecoder(in).Decode(p); err != nil {
+		return fmt.Errorf("decoding piece %q: %s", flags.Arg(0), err)
+	}
+
+	out, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("creating output: %s", err)
+	}
+	defer out.Close()
+	w := bufio.NewWriter(out)
+	if err := p.WriteWAV(w, beep.SampleRate(*rate), piece.FromBPM(*bpm), format); err != nil {
+		return fmt.Errorf("rendering %q: %s", flags.Arg(0), err)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("writing %q: %s", *output, err)
+	}
+	return out.Close()
+}
+
+// importMIDI converts a MIDI file into a piece, written as JSON on stdout
+//
+//	piano import song.mid > piece.json
+func importMIDI(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: piano import song.mid")
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("opening midi file: %s", err)
+	}
+	defer f.Close()
+
+	imported, err := midi.Decode(f, labels.NewLabels(nil))
+	if err != nil {
+		return fmt.Errorf("decoding %q: %s", args[0], err)
+	}
+	for _, warning := range imported.Warnings {
+		log.Printf("warning: %s", warning)
+	}
+	log.Printf("tempo: %.2f bpm", float64(time.Minute)/float64(imported.Beat))
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(imported.Piece)
+}
+
+// exportMIDI converts a piece into a MIDI file
+//
+//	piano export -o song.mid piece.json
+func exportMIDI(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	output := flags.String("o", "out.mid", "the MIDI file to write")
+	bpm := flags.Int("bpm", 60, "beats per minute")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: piano export [flags] piece.json")
+	}
+
+	in, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("opening piece: %s", err)
+	}
+	defer in.Close()
+	p := &piece.Piece{}
+	if err := json.NewDecoder(in).Decode(p); err != nil {
+		return fmt.Errorf("decoding piece %q: %s", flags.Arg(0), err)
+	}
+
+	out, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("creating output: %s", err)
+	}
+	defer out.Close()
+	if err := midi.Encode(out, p, piece.FromBPM(*bpm)); err != nil {
+		return fmt.Errorf("exporting %q: %s", flags.Arg(0), err)
+	}
+	return out.Close()
+}
+
 func notePlayer() {
 	sr := beep.SampleRate(44100)
 	speaker.Init(sr, sr.N(time.Second/6))
 
-	lb := labels.NewLabels()
+	lb := labels.NewLabels(nil)
 
-	sine := wave.NewSine(wave.N(sr, 440))
-	loop := beep.Loop(-1, sine)
-	ctrl := &beep.Ctrl{Streamer: loop, Paused: false}
+	ctrl := &beep.Ctrl{Streamer: wave.NewOsc(sr, 440), Paused: false}
 
 	speaker.Play(ctrl)
 	reader := bufio.NewReader(os.Stdin)
@@ -116,7 +256,7 @@ func notePlayer() {
 				log.Fatalf("converting label %q to frequency: %s", line, err)
 			}
 			speaker.Lock()
-			ctrl.Streamer = beep.Loop(-1, wave.NewSine(sr.N(time.Second/time.Duration(freq))))
+			ctrl.Streamer = wave.NewOsc(sr, freq)
 			speaker.Unlock()
 		}
 	}
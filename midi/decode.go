@@ -0,0 +1,338 @@
+package midi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/math2001/piano/frac"
+	"github.com/math2001/piano/labels"
+	"github.com/math2001/piano/piece"
+)
+
+// Import is the result of decoding a MIDI file
+type Import struct {
+	Piece *piece.Piece
+	// Beat is the duration of one beat (a quarter note), from the first
+	// tempo event. A piece can't change tempo, so later tempo events are
+	// ignored (with a warning).
+	Beat time.Duration
+	// Warnings lists everything that was ignored or that looked wrong, but
+	// didn't prevent the import
+	Warnings []string
+}
+
+// Decode reads a Standard MIDI File (type 0 or 1) and turns every note into
+// a piece.Note. Starts and durations are in beats (quarter notes), and
+// frequencies come from lb.
+func Decode(r io.Reader, lb *labels.Labels) (*Import, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &decoder{
+		lb:        lb,
+		ignored:   make(map[string]int),
+		tempo:     -1,
+		openNotes: make(map[[2]byte][]openNote),
+	}
+
+	typ, header, data, err := readChunk(data)
+	if err != nil {
+		return nil, err
+	}
+	if typ != "MThd" || len(header) < 6 {
+		return nil, fmt.Errorf("missing header chunk: %w", ErrFormat)
+	}
+	format := binary.BigEndian.Uint16(header[0:])
+	ntracks := int(binary.BigEndian.Uint16(header[2:]))
+	division := binary.BigEndian.Uint16(header[4:])
+	if format > 1 {
+		return nil, fmt.Errorf("file type %d (only 0 and 1 are): %w", format, ErrUnsupported)
+	}
+	if division&0x8000 != 0 {
+		return nil, fmt.Errorf("SMPTE time division: %w", ErrUnsupported)
+	}
+	if division == 0 {
+		return nil, fmt.Errorf("0 ticks per quarter note: %w", ErrFormat)
+	}
+	d.ppq = int(division)
+
+	tracks := 0
+	for len(data) > 0 {
+		var chunk []byte
+		typ, chunk, data, err = readChunk(data)
+		if err != nil {
+			return nil, err
+		}
+		if typ != "MTrk" {
+			// the spec says to skip the chunks we don't know about
+			d.warn("skipped unknown chunk %q", typ)
+			continue
+		}
+		if err := d.track(chunk); err != nil {
+			return nil, fmt.Errorf("track #%d: %w", tracks, err)
+		}
+		tracks++
+	}
+	if tracks != ntracks {
+		d.warn("header says there are %d tracks, found %d", ntracks, tracks)
+	}
+
+	sort.SliceStable(d.notes, func(i, j int) bool {
+		return d.notes[i].Start.Less(d.notes[j].Start)
+	})
+
+	names := make([]string, 0, len(d.ignored))
+	for name := range d.ignored {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		d.warn("ignored %d %s event(s)", d.ignored[name], name)
+	}
+
+	tempo := d.tempo
+	if tempo == -1 {
+		tempo = defaultTempo
+	}
+	return &Import{
+		Piece:    &piece.Piece{Name: d.name, Notes: d.notes},
+		Beat:     time.Duration(tempo) * time.Microsecond,
+		Warnings: d.warnings,
+	}, nil
+}
+
+type openNote struct {
+	tick     int
+	velocity byte
+}
+
+type decoder struct {
+	lb  *labels.Labels
+	ppq int
+
+	name  string
+	tempo int // microseconds per quarter note, -1 if there hasn't been one
+	notes []piece.Note
+
+	// openNotes are the notes which have started but not stopped yet, by
+	// channel and key
+	openNotes map[[2]byte][]openNote
+
+	warnings []string
+	// ignored counts the events we skip, by name, so that we don't get a
+	// warning per event
+	ignored map[string]int
+}
+
+func (d *decoder) warn(format string, a ...interface{}) {
+	d.warnings = append(d.warnings, fmt.Sprintf(format, a...))
+}
+
+func (d *decoder) track(data []byte) error {
+	tick := 0
+	var status byte
+	for len(data) > 0 {
+		delta, n, err := readVarLen(data)
+		if err != nil {
+			return fmt.Errorf("delta time at tick %d: %w", tick, err)
+		}
+		data = data[n:]
+		tick += delta
+
+		if len(data) == 0 {
+			return fmt.Errorf("missing event at tick %d: %w", tick, ErrFormat)
+		}
+		if data[0]&0x80 != 0 {
+			status = data[0]
+			data = data[1:]
+		} else if status == 0 || status >= sysex {
+			// running status only applies to channel messages
+			return fmt.Errorf("data byte 0x%02x without a status at tick %d: %w", data[0], tick, ErrFormat)
+		}
+
+		switch {
+		case status == meta:
+			if len(data) < 1 {
+				return fmt.Errorf("truncated meta event at tick %d: %w", tick, ErrFormat)
+			}
+			typ := data[0]
+			length, n, err := readVarLen(data[1:])
+			if err != nil {
+				return fmt.Errorf("meta event length at tick %d: %w", tick, err)
+			}
+			data = data[1+n:]
+			if len(data) < length {
+				return fmt.Errorf("truncated meta event at tick %d: %w", tick, ErrFormat)
+			}
+			body := data[:length]
+			data = data[length:]
+			status = 0
+
+			switch typ {
+			case metaEndOfTrack:
+				d.closeAll(tick)
+				if len(data) > 0 {
+					d.warn("%d bytes after the end of track", len(data))
+				}
+				return nil
+			case metaTempo:
+				if len(body) != 3 {
+					return fmt.Errorf("tempo event with %d bytes at tick %d: %w", len(body), tick, ErrFormat)
+				}
+				tempo := int(body[0])<<16 | int(body[1])<<8 | int(body[2])
+				if tempo == 0 {
+					// the beat would last no time at all
+					return fmt.Errorf("tempo of 0 µs per beat at tick %d: %w", tick, ErrFormat)
+				}
+				if d.tempo == -1 {
+					if tick != 0 {
+						d.warn("first tempo event at tick %d, using it for the entire piece", tick)
+					}
+					d.tempo = tempo
+				} else if tempo != d.tempo {
+					d.warn("ignored tempo change to %d µs per beat at tick %d", tempo, tick)
+				}
+			case metaTrackName:
+				// the first one is the name of the song (in type 1 files, it's
+				// the one in the first track)
+				if d.name == "" {
+					d.name = string(body)
+				}
+			}
+			// the other meta events (texts, time and key signatures, ...) are
+			// just information, nothing to warn about
+
+		case status == sysex || status == sysexEscape:
+			length, n, err := readVarLen(data)
+			if err != nil {
+				return fmt.Errorf("sysex length at tick %d: %w", tick, err)
+			}
+			if len(data) < n+length {
+				return fmt.Errorf("truncated sysex at tick %d: %w", tick, ErrFormat)
+			}
+			data = data[n+length:]
+			d.ignored[messageNames[status]]++
+			status = 0
+
+		case status > sysex:
+			// system common and real time messages aren't allowed in files
+			return fmt.Errorf("status 0x%02x at tick %d: %w", status, tick, ErrUnsupported)
+
+		default:
+			size := 2
+			kind := status & 0xF0
+			if kind == programChange || kind == channelPressure {
+				size = 1
+			}
+			if len(data) < size {
+				return fmt.Errorf("truncated event 0x%02x at tick %d: %w", status, tick, ErrFormat)
+			}
+			body := data[:size]
+			data = data[size:]
+			channel := status & 0x0F
+
+			if kind == noteOn && body[1] != 0 {
+				key := [2]byte{channel, body[0]}
+				d.openNotes[key] = append(d.openNotes[key], openNote{tick, body[1]})
+			} else if kind == noteOn || kind == noteOff {
+				// a note on with a velocity of 0 is a note off
+				if err := d.close(channel, body[0], tick); err != nil {
+					return err
+				}
+			} else {
+				d.ignored[messageNames[kind]]++
+			}
+		}
+	}
+	d.warn("track without an end of track event")
+	d.closeAll(tick)
+	return nil
+}
+
+// close finishes the oldest open note for that key
+func (d *decoder) close(channel, key byte, tick int) error {
+	k := [2]byte{channel, key}
+	open := d.openNotes[k]
+	if len(open) == 0 {
+		d.warn("note off without a note on (channel %d, key %d, tick %d)", channel, key, tick)
+		return nil
+	}
+	d.openNotes[k] = open[1:]
+
+	start, err := frac.NewFrac(open[0].tick, d.ppq)
+	if err != nil {
+		return err
+	}
+	duration, err := frac.NewFrac(tick-open[0].tick, d.ppq)
+	if err != nil {
+		return err
+	}
+	freq, err := d.lb.FromMIDI(int(key))
+	if err != nil {
+		return err
+	}
+	d.notes = append(d.notes, piece.Note{
+		Frequency: freq,
+		Start:     start,
+		Duration:  duration,
+		Velocity:  int(open[0].velocity),
+	})
+	return nil
+}
+
+// closeAll stops every note still playing at the end of a track
+func (d *decoder) closeAll(tick int) {
+	keys := make([][2]byte, 0, len(d.openNotes))
+	for k, open := range d.openNotes {
+		if len(open) > 0 {
+			keys = append(keys, k)
+		}
+	}
+	// keep the output deterministic
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1]
+	})
+	for _, k := range keys {
+		for len(d.openNotes[k]) > 0 {
+			d.warn("note never stopped (channel %d, key %d), stopping it at the end of the track", k[0], k[1])
+			d.close(k[0], k[1], tick)
+		}
+	}
+}
+
+// readChunk returns the type and the body of the first chunk, and what's
+// after it
+func readChunk(data []byte) (typ string, body []byte, rest []byte, err error) {
+	if len(data) < 8 {
+		return "", nil, nil, fmt.Errorf("truncated chunk header: %w", ErrFormat)
+	}
+	length := binary.BigEndian.Uint32(data[4:])
+	if uint64(len(data)-8) < uint64(length) {
+		return "", nil, nil, fmt.Errorf("chunk %q is %d bytes long but only %d are left: %w", data[:4], length, len(data)-8, ErrFormat)
+	}
+	return string(data[:4]), data[8 : 8+length], data[8+length:], nil
+}
+
+// readVarLen reads a variable length quantity: 7 bits per byte, the high
+// bit is set on every byte but the last one. It returns the value and the
+// number of bytes read
+func readVarLen(data []byte) (value int, n int, err error) {
+	for n < len(data) && n < 4 {
+		b := data[n]
+		n++
+		value = value<<7 | int(b&0x7F)
+		if b&0x80 == 0 {
+			return value, n, nil
+		}
+	}
+	if n == 4 {
+		return 0, n, fmt.Errorf("variable length quantity longer than 4 bytes: %w", ErrFormat)
+	}
+	return 0, n, fmt.Errorf("truncated variable length quantity: %w", ErrFormat)
+}
@@ -0,0 +1,137 @@
+package midi
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/math2001/piano/frac"
+	"github.com/math2001/piano/labels"
+	"github.com/math2001/piano/piece"
+)
+
+// rawChunk builds a chunk out of the bytes
+func rawChunk(typ string, body ...byte) []byte {
+	return chunk(typ, body)
+}
+
+func file(chunks ...[]byte) *bytes.Reader {
+	return bytes.NewReader(bytes.Join(chunks, nil))
+}
+
+func TestDecodeType1(t *testing.T) {
+	r := file(
+		// type 1, 2 tracks, 96 ticks per quarter note
+		rawChunk("MThd", 0, 1, 0, 2, 0, 96),
+		rawChunk("MTrk",
+			0, 0xFF, 0x03, 4, 'S', 'o', 'n', 'g',
+			0, 0xFF, 0x51, 3, 0x07, 0xA1, 0x20, // 500000: 120 bpm
+			0, 0xFF, 0x2F, 0,
+		),
+		rawChunk("MTrk",
+			// A4 for a beat, with a velocity of 100
+			0, 0x90, 69, 100,
+			// running status, velocity 0 is a note off
+			0x60, 69, 0,
+			// C5 (1/3 beat after), for 1/2 beat
+			0x20, 72, 64,
+			// a control change in between, which we ignore
+			0x10, 0xB0, 7, 100,
+			0x20, 0x80, 72, 0,
+			0, 0xFF, 0x2F, 0,
+		),
+	)
+
+	actual, err := Decode(r, labels.NewLabels(nil))
+	if err != nil {
+		t.Fatalf("decoding: %s", err)
+	}
+
+	lb := labels.NewLabels(nil)
+	expected := &piece.Piece{
+		Name: "Song",
+		// 440: ***
+		// 523:     **
+		//  * : 1/6 beat
+		Notes: []piece.Note{
+			piece.Note{
+				Frequency: lb.F("A4"),
+				Duration:  frac.N(1),
+				Start:     frac.N(0),
+				Velocity:  100,
+			},
+			piece.Note{
+				Frequency: lb.F("C5"),
+				Duration:  frac.F(1, 2),
+				Start:     frac.F(4, 3),
+				Velocity:  64,
+			},
+		},
+	}
+	if !actual.Piece.Equal(expected) {
+		t.Errorf("piece: \n%v\n%v", actual.Piece, expected)
+	}
+	if actual.Beat != 500*time.Millisecond {
+		t.Errorf("beat, actual: %v, expected: %v", actual.Beat, 500*time.Millisecond)
+	}
+	if len(actual.Warnings) != 1 || !strings.Contains(actual.Warnings[0], "1 control change") {
+		t.Errorf("warnings, actual: %q, expected: %q", actual.Warnings, "ignored 1 control change event(s)")
+	}
+}
+
+func TestDecodeWarnings(t *testing.T) {
+	r := file(
+		rawChunk("MThd", 0, 0, 0, 1, 0, 4),
+		rawChunk("MTrk",
+			0, 0xFF, 0x51, 3, 0x0F, 0x42, 0x40, // 1000000: 60 bpm
+			// a note off for a note that never started
+			0, 0x80, 60, 0,
+			0, 0x90, 60, 1,
+			4, 0xFF, 0x51, 3, 0x07, 0xA1, 0x20,
+			// never stopped
+		),
+	)
+	actual, err := Decode(r, labels.NewLabels(nil))
+	if err != nil {
+		t.Fatalf("decoding: %s", err)
+	}
+	if actual.Beat != time.Second {
+		t.Errorf("beat, actual: %v, expected: %v", actual.Beat, time.Second)
+	}
+	if len(actual.Piece.Notes) != 1 || actual.Piece.Notes[0].Duration != frac.N(1) {
+		t.Errorf("notes, actual: %v, expected one note of 1 beat", actual.Piece.Notes)
+	}
+	expected := []string{"note off without", "tempo change", "without an end of track", "never stopped"}
+	if len(actual.Warnings) != len(expected) {
+		t.Fatalf("warnings, actual: %q, expected: %q", actual.Warnings, expected)
+	}
+	for i := range expected {
+		if !strings.Contains(actual.Warnings[i], expected[i]) {
+			t.Errorf("warning #%d, actual: %q, expected: %q", i, actual.Warnings[i], expected[i])
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var rows = []struct {
+		name     string
+		file     *bytes.Reader
+		expected error
+	}{
+		{"no header", file(rawChunk("MTrk")), ErrFormat},
+		{"type 2", file(rawChunk("MThd", 0, 2, 0, 1, 0, 96)), ErrUnsupported},
+		{"smpte", file(rawChunk("MThd", 0, 1, 0, 1, 0xE7, 0x28)), ErrUnsupported},
+		{"truncated chunk", file(rawChunk("MThd", 0, 0, 0, 1, 0, 96), []byte("MTrk\x00\x00\x00\x09\x00")), ErrFormat},
+		{"running status without status", file(rawChunk("MThd", 0, 0, 0, 1, 0, 96), rawChunk("MTrk", 0, 60, 100)), ErrFormat},
+		{"truncated event", file(rawChunk("MThd", 0, 0, 0, 1, 0, 96), rawChunk("MTrk", 0, 0x90, 60)), ErrFormat},
+		{"tempo of 0", file(rawChunk("MThd", 0, 0, 0, 1, 0, 96), rawChunk("MTrk", 0, 0xFF, 0x51, 3, 0, 0, 0)), ErrFormat},
+	}
+	for _, row := range rows {
+		_, err := Decode(row.file, labels.NewLabels(nil))
+		if !errors.Is(err, row.expected) {
+			t.Errorf("%s, actual: %v, expected: %v", row.name, err, row.expected)
+		}
+	}
+}
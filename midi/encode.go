@@ -0,0 +1,289 @@
+package midi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/math2001/piano/frac"
+	"github.com/math2001/piano/labels"
+	"github.com/math2001/piano/piece"
+)
+
+// minPPQ is the lowest resolution we write. The PPQ only needs to be a
+// multiple of every denominator, but some programs don't like tiny ones.
+const minPPQ = 96
+
+// bendRange is how far (in cents) the maximum pitch bend goes, in either
+// direction. 2 semitones is the General MIDI default
+const bendRange = 200
+
+// bendCenter is the pitch bend value which doesn't change the pitch
+const bendCenter = 8192
+
+// drumChannel is the General MIDI percussion channel, we never use it
+const drumChannel = 9
+
+// Encode writes the piece as a type 1 Standard MIDI File. The first track
+// has the name of the piece and its tempo (beat is the duration of a quarter
+// note), the second one has the notes. Frequencies which aren't exactly on a
+// key get a pitch bend, and because a pitch bend changes a whole channel,
+// those notes are spread over the other channels.
+func Encode(w io.Writer, p *piece.Piece, beat time.Duration) error {
+	if err := checkNotes(p); err != nil {
+		return err
+	}
+	ppq, err := chooseTicks(p)
+	if err != nil {
+		return err
+	}
+
+	var conductor track
+	if p.Name != "" {
+		conductor.add(0, 0, append([]byte{meta, metaTrackName}, withVarLen([]byte(p.Name))...)...)
+	}
+	tempo := beat.Microseconds()
+	if tempo <= 0 || tempo >= 1<<24 {
+		return fmt.Errorf("beat of %v (tempo is stored on 3 bytes): %w", beat, ErrUnsupported)
+	}
+	conductor.add(0, 0, meta, metaTempo, 3, byte(tempo>>16), byte(tempo>>8), byte(tempo))
+
+	notes, err := encodeNotes(p, ppq)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(header(1, 2, ppq))
+	buf.Write(conductor.chunk())
+	buf.Write(notes.chunk())
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// checkNotes makes sure every note can be written. It has to run before
+// chooseTicks, which expects sensible starts and durations
+func checkNotes(p *piece.Piece) error {
+	for _, note := range p.Notes {
+		// a note off at the same tick as its note on would be sorted first,
+		// and the note would never stop. And there's no way to write a
+		// negative time
+		if note.Duration.Compare(frac.N(0)) <= 0 {
+			return fmt.Errorf("note at beat %s lasts %s beats (needs to be positive): %w", note.Start, note.Duration, ErrUnsupported)
+		}
+		if note.Start.Less(frac.N(0)) {
+			return fmt.Errorf("note starts at beat %s (needs to be positive): %w", note.Start, ErrUnsupported)
+		}
+	}
+	return nil
+}
+
+// chooseTicks returns a number of ticks per quarter note such that every
+// start and every duration is a whole number of ticks.
+func chooseTicks(p *piece.Piece) (int, error) {
+	ppq := 1
+	for _, note := range p.Notes {
+		for _, den := range []int{note.Start.Den(), note.Duration.Den()} {
+			ppq = lcm(ppq, den)
+			if ppq > 0x7FFF {
+				return 0, fmt.Errorf("need more than %d ticks per quarter note (lowest common multiple of the denominators): %w", 0x7FFF, ErrUnsupported)
+			}
+		}
+	}
+	if ppq < minPPQ {
+		// keep it a multiple
+		ppq *= (minPPQ + ppq - 1) / ppq
+	}
+	return ppq, nil
+}
+
+// channelState keeps track of what's playing on a channel, to know whether
+// a bent note can go on it
+type channelState struct {
+	bend int
+	// until is the tick at which the last note on the channel stops
+	until int
+}
+
+func encodeNotes(p *piece.Piece, ppq int) (*track, error) {
+	notes := make([]piece.Note, len(p.Notes))
+	copy(notes, p.Notes)
+	sort.SliceStable(notes, func(i, j int) bool {
+		return notes[i].Start.Less(notes[j].Start)
+	})
+
+	lb := labels.NewLabels(nil)
+	var t track
+	var channels [16]channelState
+	for i := range channels {
+		channels[i].bend = bendCenter
+	}
+	for _, note := range notes {
+		// GetStreamer doesn't play them either
+		velocity := toVelocity(note.Amplitude())
+		if velocity == 0 {
+			continue
+		}
+		start, err := toTicks(note.Start, ppq)
+		if err != nil {
+			return nil, fmt.Errorf("note at beat %s: %w", note.Start, err)
+		}
+		fend, err := note.End()
+		if err != nil {
+			return nil, err
+		}
+		end, err := toTicks(fend, ppq)
+		if err != nil {
+			return nil, fmt.Errorf("note at beat %s: %w", note.Start, err)
+		}
+
+		// the bend is relative to 12-TET at 440 Hz, that's what every synth
+		// plays by default
+		key, cents, err := lb.NearestMIDI(note.Frequency)
+		if err != nil {
+			return nil, fmt.Errorf("note at beat %s: %w", note.Start, err)
+		}
+		bend := bendCenter + int(math.Round(cents/bendRange*bendCenter))
+
+		// notes right on a key go on channel 0, which is never bent. The
+		// others go on a channel which is either free or already bent by the
+		// same amount
+		channel := 0
+		if bend != bendCenter {
+			channel = -1
+			for c := 1; c < len(channels); c++ {
+				if c == drumChannel {
+					continue
+				}
+				if channels[c].until <= start || channels[c].bend == bend {
+					channel = c
+					break
+				}
+			}
+			if channel == -1 {
+				return nil, fmt.Errorf("too many detuned notes playing at the same time at beat %s: %w", note.Start, ErrUnsupported)
+			}
+			if channels[channel].bend != bend {
+				t.add(start, orderBend, pitchBend|byte(channel), byte(bend&0x7F), byte(bend>>7))
+				channels[channel].bend = bend
+			}
+		}
+		if end > channels[channel].until {
+			channels[channel].until = end
+		}
+
+		t.add(start, orderOn, noteOn|byte(channel), byte(key), byte(velocity))
+		t.add(end, orderOff, noteOff|byte(channel), byte(key), 0)
+	}
+	return &t, nil
+}
+
+// toVelocity converts the note's amplitude (with both its velocity and its
+// volume) to a MIDI velocity. 0 means it's silent. MIDI can't go louder than
+// 127, so a note with a positive volume is just as loud as one without
+func toVelocity(amplitude float64) int {
+	if amplitude <= 0 {
+		return 0
+	}
+	velocity := int(math.Round(amplitude * piece.MaxVelocity))
+	if velocity < 1 {
+		// a velocity of 0 is a note off
+		velocity = 1
+	} else if velocity > piece.MaxVelocity {
+		velocity = piece.MaxVelocity
+	}
+	return velocity
+}
+
+// toTicks returns frac.ErrOverflow if the tick doesn't fit in an int
+func toTicks(f frac.Frac, ppq int) (int, error) {
+	// chooseTicks makes sure it's a whole number
+	ticks, err := f.CheckedMultiply(frac.N(ppq))
+	return ticks.Num(), err
+}
+
+// the order of events which happen on the same tick: stop the previous notes
+// first, and bend before starting a note
+const (
+	orderOff = iota
+	orderBend
+	orderOn
+)
+
+type event struct {
+	tick, order int
+	data        []byte
+}
+
+type track struct {
+	events []event
+}
+
+func (t *track) add(tick, order int, data ...byte) {
+	t.events = append(t.events, event{tick, order, data})
+}
+
+// chunk returns the MTrk chunk, with the end of track event
+func (t *track) chunk() []byte {
+	sort.SliceStable(t.events, func(i, j int) bool {
+		a, b := t.events[i], t.events[j]
+		if a.tick != b.tick {
+			return a.tick < b.tick
+		}
+		return a.order < b.order
+	})
+
+	var body bytes.Buffer
+	tick := 0
+	for _, e := range t.events {
+		body.Write(varLen(e.tick - tick))
+		body.Write(e.data)
+		tick = e.tick
+	}
+	body.Write([]byte{0, meta, metaEndOfTrack, 0})
+	return chunk("MTrk", body.Bytes())
+}
+
+func header(format, ntracks, ppq int) []byte {
+	body := make([]byte, 6)
+	binary.BigEndian.PutUint16(body[0:], uint16(format))
+	binary.BigEndian.PutUint16(body[2:], uint16(ntracks))
+	binary.BigEndian.PutUint16(body[4:], uint16(ppq))
+	return chunk("MThd", body)
+}
+
+func chunk(typ string, body []byte) []byte {
+	c := make([]byte, 8, 8+len(body))
+	copy(c, typ)
+	binary.BigEndian.PutUint32(c[4:], uint32(len(body)))
+	return append(c, body...)
+}
+
+// varLen encodes n as a variable length quantity (see readVarLen)
+func varLen(n int) []byte {
+	b := []byte{byte(n & 0x7F)}
+	for n >>= 7; n > 0; n >>= 7 {
+		b = append([]byte{byte(n&0x7F) | 0x80}, b...)
+	}
+	return b
+}
+
+// withVarLen prefixes data with its length
+func withVarLen(data []byte) []byte {
+	return append(varLen(len(data)), data...)
+}
+
+func gcd(a, b int) int {
+	if b == 0 {
+		return a
+	}
+	return gcd(b, a%b)
+}
+
+func lcm(a, b int) int {
+	return a / gcd(a, b) * b
+}
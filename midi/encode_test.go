@@ -0,0 +1,268 @@
+package midi
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/math2001/piano/frac"
+	"github.com/math2001/piano/labels"
+	"github.com/math2001/piano/piece"
+)
+
+func TestEncodeRoundTrip(t *testing.T) {
+	lb := labels.NewLabels(nil)
+	p := &piece.Piece{
+		Name: "Round trip",
+		//  * : 1/6 beat
+		// 440: ******
+		// 523:   ** ***
+		// 698:  *
+		Notes: []piece.Note{
+			piece.Note{
+				Frequency: lb.F("A4"),
+				Duration:  frac.N(1),
+				Start:     frac.N(0),
+				Velocity:  100,
+			},
+			piece.Note{
+				Frequency: lb.F("F5"),
+				Duration:  frac.F(1, 6),
+				Start:     frac.F(1, 6),
+				Velocity:  20,
+			},
+			piece.Note{
+				Frequency: lb.F("C5"),
+				Duration:  frac.F(1, 3),
+				Start:     frac.F(1, 3),
+				Velocity:  127,
+			},
+			piece.Note{
+				Frequency: lb.F("C5"),
+				Duration:  frac.F(1, 2),
+				Start:     frac.F(5, 6),
+				Velocity:  127,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, p, 750*time.Millisecond); err != nil {
+		t.Fatalf("encoding: %s", err)
+	}
+	actual, err := Decode(&buf, labels.NewLabels(nil))
+	if err != nil {
+		t.Fatalf("decoding: %s", err)
+	}
+	if !actual.Piece.Equal(p) {
+		t.Errorf("piece: \n%v\n%v", actual.Piece, p)
+	}
+	if actual.Beat != 750*time.Millisecond {
+		t.Errorf("beat, actual: %v, expected: %v", actual.Beat, 750*time.Millisecond)
+	}
+	if len(actual.Warnings) != 0 {
+		t.Errorf("warnings, actual: %q, expected: none", actual.Warnings)
+	}
+}
+
+func TestEncodeTicks(t *testing.T) {
+	p := &piece.Piece{
+		Notes: []piece.Note{
+			piece.Note{Frequency: 440, Duration: frac.F(1, 5), Start: frac.F(2, 7)},
+			piece.Note{Frequency: 440, Duration: frac.F(1, 3), Start: frac.N(1)},
+		},
+	}
+	actual, err := chooseTicks(p)
+	if err != nil {
+		t.Fatalf("choosing ticks: %s", err)
+	}
+	// 5*7*3 = 105
+	if actual != 105 {
+		t.Errorf("ppq, actual: %d, expected: %d", actual, 105)
+	}
+
+	p.Notes = []piece.Note{
+		piece.Note{Frequency: 440, Duration: frac.F(1, 3), Start: frac.F(1, 4)},
+	}
+	actual, err = chooseTicks(p)
+	if err != nil {
+		t.Fatalf("choosing ticks: %s", err)
+	}
+	// 12 is too small, so it's scaled up
+	if actual != 96 {
+		t.Errorf("ppq, actual: %d, expected: %d", actual, 96)
+	}
+
+	p.Notes = []piece.Note{
+		piece.Note{Frequency: 440, Duration: frac.F(1, 1009), Start: frac.F(1, 1013)},
+	}
+	if _, err := chooseTicks(p); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("huge ppq, actual: %v, expected: %v", err, ErrUnsupported)
+	}
+}
+
+func TestEncodePitchBend(t *testing.T) {
+	p := &piece.Piece{
+		Notes: []piece.Note{
+			// right on A4
+			piece.Note{Frequency: 440, Duration: frac.N(1), Start: frac.N(0)},
+			// a quarter tone above A4 (50 cents)
+			piece.Note{Frequency: 452.89, Duration: frac.N(1), Start: frac.N(0)},
+			// 40 cents below A4, at the same time, so it needs another channel
+			piece.Note{Frequency: 440 * math.Pow(2, -40.0/1200), Duration: frac.N(1), Start: frac.N(0)},
+		},
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, p, 500*time.Millisecond); err != nil {
+		t.Fatalf("encoding: %s", err)
+	}
+	b := buf.Bytes()
+
+	// 8192 + 8192/4 = 10240 and 8192 - 8192/5 = 6554, the two 7 bit halves
+	// (least significant first)
+	for _, expected := range [][]byte{
+		{pitchBend | 1, 10240 & 0x7F, 10240 >> 7},
+		{pitchBend | 2, 6554 & 0x7F, 6554 >> 7},
+		{noteOn | 0, 69},
+		{noteOn | 1, 69},
+		{noteOn | 2, 69},
+	} {
+		if !bytes.Contains(b, expected) {
+			t.Errorf("missing event % x in\n% x", expected, b)
+		}
+	}
+
+	// the decoder doesn't know about pitch bend, but the keys should be right
+	actual, err := Decode(bytes.NewReader(b), labels.NewLabels(nil))
+	if err != nil {
+		t.Fatalf("decoding: %s", err)
+	}
+	if len(actual.Piece.Notes) != 3 {
+		t.Fatalf("notes, actual: %d, expected: %d", len(actual.Piece.Notes), 3)
+	}
+	for _, note := range actual.Piece.Notes {
+		if note.Frequency != 440 {
+			t.Errorf("frequency, actual: %f, expected: %f", note.Frequency, 440.0)
+		}
+	}
+}
+
+func TestEncodeInvalidNotes(t *testing.T) {
+	var rows = []struct {
+		name string
+		note piece.Note
+	}{
+		{"zero length", piece.Note{Frequency: 440, Duration: frac.N(0), Start: frac.N(1)}},
+		{"negative length", piece.Note{Frequency: 440, Duration: frac.F(-1, 2), Start: frac.N(1)}},
+		{"negative start", piece.Note{Frequency: 440, Duration: frac.N(1), Start: frac.F(-1, 2)}},
+		{"no duration", piece.Note{Frequency: 440, Start: frac.N(1)}},
+	}
+	for _, row := range rows {
+		p := &piece.Piece{Notes: []piece.Note{row.note}}
+		if err := Encode(&bytes.Buffer{}, p, 500*time.Millisecond); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("%s, actual: %v, expected: %v", row.name, err, ErrUnsupported)
+		}
+	}
+}
+
+func TestEncodeNoStart(t *testing.T) {
+	// a note without a start (like one read from JSON) starts at 0
+	p := &piece.Piece{Notes: []piece.Note{{Frequency: 440, Duration: frac.F(1, 2)}}}
+	var buf bytes.Buffer
+	if err := Encode(&buf, p, 500*time.Millisecond); err != nil {
+		t.Fatalf("encoding: %s", err)
+	}
+	imported, err := Decode(&buf, labels.NewLabels(nil))
+	if err != nil {
+		t.Fatalf("decoding: %s", err)
+	}
+	if len(imported.Piece.Notes) != 1 || imported.Piece.Notes[0].Start != frac.N(0) {
+		t.Errorf("actual: %v, expected: one note at beat 0", imported.Piece.Notes)
+	}
+}
+
+func TestEncodeVelocity(t *testing.T) {
+	var rows = []struct {
+		volume   float64
+		velocity int
+		expected int
+	}{
+		{0, 0, 127},
+		{0, 64, 64},
+		{-0.5, 64, 32},
+		{-0.5, 0, 64},
+		// MIDI can't go louder than that
+		{1, 100, 127},
+		{0, 200, 127},
+		// just above silent
+		{-0.999, 127, 1},
+	}
+	for _, row := range rows {
+		p := &piece.Piece{Notes: []piece.Note{{Frequency: 440, Duration: frac.N(1), Volume: row.volume, Velocity: row.velocity}}}
+		var buf bytes.Buffer
+		if err := Encode(&buf, p, 500*time.Millisecond); err != nil {
+			t.Fatalf("volume: %f, velocity: %d, encoding: %s", row.volume, row.velocity, err)
+		}
+		imported, err := Decode(&buf, labels.NewLabels(nil))
+		if err != nil {
+			t.Fatalf("volume: %f, velocity: %d, decoding: %s", row.volume, row.velocity, err)
+		}
+		if len(imported.Piece.Notes) != 1 || imported.Piece.Notes[0].Velocity != row.expected {
+			t.Errorf("volume: %f, velocity: %d, actual: %v, expected: velocity %d", row.volume, row.velocity, imported.Piece.Notes, row.expected)
+		}
+	}
+
+	// silent notes aren't written at all
+	p := &piece.Piece{Notes: []piece.Note{
+		{Frequency: 440, Duration: frac.N(1), Velocity: -1},
+		{Frequency: 440, Duration: frac.N(1), Volume: -1},
+		{Frequency: 523.25, Duration: frac.N(1), Start: frac.N(1)},
+	}}
+	var buf bytes.Buffer
+	if err := Encode(&buf, p, 500*time.Millisecond); err != nil {
+		t.Fatalf("silent notes, encoding: %s", err)
+	}
+	imported, err := Decode(&buf, labels.NewLabels(nil))
+	if err != nil {
+		t.Fatalf("silent notes, decoding: %s", err)
+	}
+	if len(imported.Piece.Notes) != 1 || imported.Piece.Notes[0].Start != frac.N(1) {
+		t.Errorf("silent notes, actual: %v, expected: only the one at beat 1", imported.Piece.Notes)
+	}
+}
+
+func TestEncodeFrequencyErrors(t *testing.T) {
+	var rows = []struct {
+		freq float64
+		err  error
+	}{
+		{0, labels.ErrInvalidFrequency},
+		{math.Inf(1), labels.ErrInvalidFrequency},
+		{20000, labels.ErrOutOfRange},
+	}
+	for _, row := range rows {
+		p := &piece.Piece{Notes: []piece.Note{{Frequency: row.freq, Duration: frac.N(1), Start: frac.N(0)}}}
+		if err := Encode(&bytes.Buffer{}, p, 500*time.Millisecond); !errors.Is(err, row.err) {
+			t.Errorf("%f Hz, actual: %v, expected: %v", row.freq, err, row.err)
+		}
+	}
+}
+
+func TestEncodeOverflow(t *testing.T) {
+	const maxInt = int(^uint(0) >> 1)
+	var rows = []struct {
+		name string
+		note piece.Note
+	}{
+		{"end", piece.Note{Frequency: 440, Duration: frac.N(maxInt), Start: frac.N(1)}},
+		{"ticks", piece.Note{Frequency: 440, Duration: frac.N(1), Start: frac.N(maxInt / 2)}},
+	}
+	for _, row := range rows {
+		p := &piece.Piece{Notes: []piece.Note{row.note}}
+		if err := Encode(&bytes.Buffer{}, p, 500*time.Millisecond); !errors.Is(err, frac.ErrOverflow) {
+			t.Errorf("%s, actual: %v, expected: %v", row.name, err, frac.ErrOverflow)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// Package midi converts between Standard MIDI Files and pieces
+package midi
+
+import (
+	"errors"
+)
+
+var ErrFormat = errors.New("invalid midi file")
+var ErrUnsupported = errors.New("unsupported midi feature")
+
+// defaultTempo is the duration of a quarter note when there is no tempo event
+// (120 bpm), in microseconds
+const defaultTempo = 500000
+
+// status bytes (the high nibble for channel messages)
+const (
+	noteOff         = 0x80
+	noteOn          = 0x90
+	keyPressure     = 0xA0
+	controlChange   = 0xB0
+	programChange   = 0xC0
+	channelPressure = 0xD0
+	pitchBend       = 0xE0
+	sysex           = 0xF0
+	sysexEscape     = 0xF7
+	meta            = 0xFF
+)
+
+// meta event types
+const (
+	metaTrackName  = 0x03
+	metaEndOfTrack = 0x2F
+	metaTempo      = 0x51
+)
+
+var messageNames = map[byte]string{
+	keyPressure:     "key pressure",
+	controlChange:   "control change",
+	programChange:   "program change",
+	channelPressure: "channel pressure",
+	pitchBend:       "pitch bend",
+	sysex:           "system exclusive",
+	sysexEscape:     "system exclusive",
+}
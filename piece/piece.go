@@ -2,13 +2,16 @@ package piece
 
 import (
 	"fmt"
+	"io"
+	"math"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/faiface/beep"
-	"github.com/faiface/beep/effects"
 	"github.com/math2001/piano/frac"
+	"github.com/math2001/piano/labels"
+	"github.com/math2001/piano/wav"
 	"github.com/math2001/piano/wave"
 )
 
@@ -16,8 +19,14 @@ import (
 // right now, it's very simplistic
 type Note struct {
 	// Volume == 0 -> remains unchanged. < 0 decrease volume, > 0 increase volume
+	// (the amplitude gets multiplied by 1+Volume, like effects.Gain)
 	Volume float64 `json:"volume"`
 
+	// Velocity is like a MIDI velocity, from 1 (softest) to 127 (loudest).
+	// 0 means it isn't set, and plays like 127. Anything above 127 plays like
+	// 127 as well, and negative velocities are silent.
+	Velocity int `json:"velocity"`
+
 	// Frequency is the pitch of the note
 	Frequency float64 `json:"frequency"`
 
@@ -29,162 +38,129 @@ type Note struct {
 
 	// Start is the starting time, as a scaling of the beat
 	Start frac.Frac `json:"start"`
+
+	// Envelope shapes the note's amplitude. If it's zero, the piece's
+	// Envelope is used. The release happens after End().
+	Envelope wave.ADSR `json:"envelope"`
+}
+
+// End returns the beat at which the note stops (Start + Duration), or
+// frac.ErrOverflow if it doesn't fit in a frac.Frac
+func (n Note) End() (frac.Frac, error) {
+	end, err := n.Start.CheckedAdd(n.Duration)
+	if err != nil {
+		return frac.Frac{}, fmt.Errorf("note at beat %s lasting %s beats: %w", n.Start, n.Duration, err)
+	}
+	return end, nil
 }
 
-func (n Note) End() frac.Frac {
-	return n.Start.Add(n.Duration)
+// MaxVelocity is the velocity at which the note is played unchanged
+const MaxVelocity = 127
+
+// Amplitude is the coefficient the note's wave gets multiplied by, combining
+// both Volume and Velocity
+func (n Note) Amplitude() float64 {
+	amp := 1 + n.Volume
+	if amp < 0 {
+		amp = 0
+	}
+	// velocities outside of the MIDI range are clamped (negative ones are
+	// silent, not inverted)
+	switch {
+	case n.Velocity < 0:
+		return 0
+	case n.Velocity > 0 && n.Velocity < MaxVelocity:
+		amp *= float64(n.Velocity) / MaxVelocity
+	}
+	return amp
+}
+
+// glyph is the character Render uses to draw the note
+func (n Note) glyph() string {
+	amp := n.Amplitude()
+	if amp > 1 {
+		return "#"
+	} else if amp < 0.5 {
+		return "."
+	}
+	return "*"
 }
 
 // Piece is a collection of notes
 type Piece struct {
 	Name string `json:"name"`
+	// Envelope is the default envelope for notes which don't have one. If
+	// it's zero as well, the notes just start and stop at full amplitude.
+	Envelope wave.ADSR `json:"envelope"`
+	// Waveform is the shape of every note's wave (sine if empty)
+	Waveform wave.Shape `json:"waveform"`
+	// PulseWidth is only used by the pulse waveform (see wave.NewPulse). 0
+	// means 0.5, a square wave
+	PulseWidth float64 `json:"pulse_width"`
+	// Gain multiplies every note (0 means 1). The notes are added up as they
+	// are, and the sum is clipped between -1 and 1, so lower it for pieces
+	// with chords.
+	Gain float64 `json:"gain"`
 	// float64 is a scalar describing the start time of each note relative to
 	// the start of the piece (it scales one beat)
 	Notes []Note `json:"notes"`
 }
 
-// a block is like a note but can have multiple streamer (which we mix).
-// the streamers are all mixed from start during duration.
-type block struct {
-	duration frac.Frac
-	// we don't need to know about start
-	start frac.Frac
-	// this will be become streamers when I switch to samples instead of
-	// sine waves.
-	frequencies []float64
-}
-
-func (b *block) end() frac.Frac {
-	return b.start.Add(b.duration)
-}
-
-func (b *block) equal(target block) bool {
-
-	equal := b.start == target.start
-	equal = equal && b.duration == target.duration
-	equal = equal && len(b.frequencies) == len(target.frequencies)
-	// do the cheap comparaissons first
-	if !equal {
-		return false
+// GetStreamer returns a streamer playing the whole piece. Each note is its own
+// voice, running continuously from its start to its end, and all the voices
+// are mixed sample by sample. That way, a note held while others start and
+// stop keeps its phase (no clicks at the boundaries).
+func (p *Piece) GetStreamer(sr beep.SampleRate, beat time.Duration) (beep.Streamer, error) {
+	m := &mix{gain: p.Gain}
+	if m.gain == 0 {
+		m.gain = 1
 	}
-
-	for i := range b.frequencies {
-		if b.frequencies[i] != target.frequencies[i] {
-			return false
+	for _, note := range p.Notes {
+		osc, err := wave.NewShape(sr, note.Frequency, p.Waveform, p.PulseWidth)
+		if err != nil {
+			return nil, fmt.Errorf("waveform: %w", err)
 		}
-	}
-
-	return true
-}
-
-// Play assumes that the speaker has been initialized
-func (p *Piece) GetStreamer(sr beep.SampleRate, beat time.Duration) beep.Streamer {
-	// how the algorithm works
-	// get every marker
-	// (a marker is the start or the end of a note. It's just a number)
-	//
-	// for each marker
-	//     find every note that intersect (start <= prev_marker && end >= current_marker)
-	//     mix all those notes together from prev_marker to current_marker
-
-	blocks := p.intersectionBlocks()
-
-	// a simple slice that we give to a sequencer (ie if no sound is going to
-	// be played, we need to pass in silence, because otherwise the next block
-	// will play straight away)
-
-	var streamers []beep.Streamer
-	for _, block := range blocks {
-		nsamples := sr.N(time.Duration(float64(beat) * block.duration.Float()))
-
-		var streamer beep.Streamer
-		if len(block.frequencies) == 0 {
-			streamer = beep.Silence(-1)
-		} else if len(block.frequencies) == 1 {
-			// FIXME: please do some caching. At least profile to check the
-			// cost
-			streamer = beep.Loop(-1, wave.NewSine(wave.N(sr, block.frequencies[0])))
-		} else {
-			mixer := &beep.Mixer{}
-			for _, freq := range block.frequencies {
-				mixer.Add(beep.Loop(-1, wave.NewSine(wave.N(sr, freq))))
-			}
-			// mixer only sums up the samples. That means if we sum up to 1s,
-			// we get a two which isn't allowed. Instead, we want to take the
-			// *average* of the different streamers. This is what gain does
-			// here...
-			streamer = &effects.Gain{
-				Streamer: mixer,
-				// this hacky thing is due to how Gain is implemented...
-				Gain: 1.0/float64(mixer.Len()) - 1.0,
-			}
+		end, err := note.End()
+		if err != nil {
+			return nil, err
 		}
-		streamers = append(streamers, beep.Take(nsamples, streamer))
-	}
-
-	return beep.Seq(streamers...)
-}
-
-func (p *Piece) intersectionBlocks() []block {
-
-	markers := p.getMarkers()
-
-	var blocks []block
-
-	for i, currentMarker := range markers {
-		if i == 0 {
-			continue
+		v := voice{
+			start:     toSamples(sr, beat, note.Start),
+			end:       toSamples(sr, beat, end),
+			amplitude: note.Amplitude(),
+			streamer:  osc,
 		}
-		prevMarker := markers[i-1]
-		currentblock := block{
-			start:    prevMarker,
-			duration: currentMarker.Minus(prevMarker),
+		adsr := note.Envelope
+		if adsr.IsZero() {
+			adsr = p.Envelope
 		}
-		// FIXME: we can limit what how many notes are looping over here...
-		for _, note := range p.Notes {
-			intersect := note.Start.Float() <= prevMarker.Float()
-			intersect = intersect && note.End().Float() >= currentMarker.Float()
-			if intersect {
-				currentblock.frequencies = append(currentblock.frequencies, note.Frequency)
-			}
+		if !adsr.IsZero() {
+			env := wave.NewEnvelope(sr, adsr, v.end-v.start, v.streamer)
+			v.end = v.start + env.Len()
+			v.streamer = env
+		}
+		m.voices = append(m.voices, v)
+		if v.end > m.len {
+			m.len = v.end
 		}
-		blocks = append(blocks, currentblock)
 	}
-	return blocks
+	m.init()
+	return m, nil
 }
 
-// markers are where the notes start or finish
-func (p *Piece) getMarkers() []frac.Frac {
-
-	markers := make([]frac.Frac, len(p.Notes)*2+1)
-
-	// make sure there is a marker at 0, to allow piece to start with complete
-	// silence... (this is due to the fact that intersectionBlocks just bases
-	// itself on markers exclusively, and hence goes straight to the first marker
-	// all the time)
-	markers[0] = frac.N(0)
-
-	for i, note := range p.Notes {
-		markers[i*2+1] = note.Start
-		markers[i*2+2] = note.End()
+// WriteWAV renders the entire piece into a WAV file. It doesn't need a sound
+// card.
+func (p *Piece) WriteWAV(w io.Writer, sr beep.SampleRate, beat time.Duration, format wav.Format) error {
+	streamer, err := p.GetStreamer(sr, beat)
+	if err != nil {
+		return err
 	}
-
-	sort.SliceStable(markers, func(i, j int) bool {
-		return markers[i].Float() < markers[j].Float()
-	})
-
-	// remove duplicates
-	j := 1
-	for i := 1; i < len(markers); i++ {
-		if markers[i] != markers[i-1] {
-			markers[j] = markers[i]
-			j++
-		}
-	}
-	return markers[:j]
+	return wav.Encode(w, streamer, sr, format)
 }
 
-func (p *Piece) Render() {
+// Render prints the piece, one row per frequency. It returns
+// frac.ErrOverflow if the notes are too precise to be drawn on a grid
+func (p *Piece) Render() error {
 	// we make duration and start integers (fraction with denominator 1)
 	// so that every character is the lower fraction of time in the piece
 	var dens []int
@@ -195,37 +171,62 @@ func (p *Piece) Render() {
 		dens = append(dens, note.Duration.Den(), note.Start.Den())
 	}
 
-	// compute the smallest  product of all the different primes composing all
-	// the denominators
-	sort.Slice(dens, func(i, j int) bool {
-		return dens[i] > dens[j]
-	})
-
-	scaler := 1
+	// compute the lowest common multiple of all the denominators
+	k := frac.N(1)
 	for _, n := range dens {
-		if scaler%n != 0 {
-			scaler = scaler * n
+		// n/k simplified is n/gcd over k/gcd, so that's what's missing from k
+		// to be a multiple of n
+		missing := frac.F(n, k.Num()).Num()
+		var err error
+		if k, err = k.CheckedMultiply(frac.N(missing)); err != nil {
+			return fmt.Errorf("lowest common multiple of the denominators: %w", err)
 		}
 	}
 
-	k := frac.N(scaler)
+	// name the rows after the notes (with how out of tune they are, if they
+	// are)
+	lb := labels.NewLabels(nil)
+	var freqs []float64
+	names := make(map[float64]string)
+	width := 0
+	for freq := range frequencies {
+		freqs = append(freqs, freq)
+		label, _, cents, err := lb.Label(freq, labels.Sharps)
+		if err != nil {
+			names[freq] = fmt.Sprintf("%.0f", freq)
+		} else if math.Abs(cents) >= 1 {
+			names[freq] = fmt.Sprintf("%s%+.0fc", label, cents)
+		} else {
+			names[freq] = label
+		}
+		if len(names[freq]) > width {
+			width = len(names[freq])
+		}
+	}
+	sort.Float64s(freqs)
 
 	// FIXME: use unicode symbols for beat!!!
 	if k.Num() != 1 {
-		fmt.Printf(" * : 1/%d beat\n", k.Num())
+		fmt.Printf("%-*s: 1/%d beat\n", width, " *", k.Num())
 	}
 
 	type block struct{ start, width int }
 	overlaps := make(map[float64][]block)
 
-	// FIXME: sort frequencies!
-	for freq, notes := range frequencies {
-		fmt.Printf("%3.0f: ", freq)
+	for _, freq := range freqs {
+		notes := frequencies[freq]
+		fmt.Printf("%-*s: ", width, names[freq])
 		// we assume the notes are sorted
 		cursor := 0
 		for _, note := range notes {
-			fstart := note.Start.Multiply(k)
-			fwidth := note.Duration.Multiply(k)
+			fstart, err := note.Start.CheckedMultiply(k)
+			if err != nil {
+				return err
+			}
+			fwidth, err := note.Duration.CheckedMultiply(k)
+			if err != nil {
+				return err
+			}
 			if fstart.Den() != 1 {
 				panic("start denominator != 1 (scaling doesn't work)")
 			}
@@ -243,7 +244,7 @@ func (p *Piece) Render() {
 				start = cursor
 			}
 			fmt.Print(strings.Repeat(" ", start-cursor))
-			fmt.Print(strings.Repeat("*", width))
+			fmt.Print(strings.Repeat(note.glyph(), width))
 			cursor = start + width
 		}
 		fmt.Println()
@@ -252,18 +253,26 @@ func (p *Piece) Render() {
 	// too lazy to do that right now...
 	if len(overlaps) > 0 {
 		fmt.Println("overlaps:")
-		for freq, blocks := range overlaps {
-			fmt.Printf("%3.0f: | ", freq)
+		for _, freq := range freqs {
+			blocks, ok := overlaps[freq]
+			if !ok {
+				continue
+			}
+			fmt.Printf("%-*s: | ", width, names[freq])
 			for _, block := range blocks {
 				fmt.Printf("at %d width %d | ", block.start, block.width)
 			}
 		}
 		fmt.Println()
 	}
+	return nil
 }
 
 func (a *Piece) Equal(b *Piece) bool {
-	if a.Name != b.Name || len(a.Notes) != len(b.Notes) {
+	if a.Name != b.Name || a.Envelope != b.Envelope || len(a.Notes) != len(b.Notes) {
+		return false
+	}
+	if a.Waveform != b.Waveform || a.PulseWidth != b.PulseWidth || a.Gain != b.Gain {
 		return false
 	}
 
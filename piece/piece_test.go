@@ -1,47 +1,109 @@
 package piece
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"math"
 	"testing"
 	"time"
 
+	"github.com/faiface/beep"
 	"github.com/math2001/piano/frac"
+	"github.com/math2001/piano/wav"
+	"github.com/math2001/piano/wave"
 )
 
-func TestIntersectionSimultaneous(t *testing.T) {
+func TestFromBPM(t *testing.T) {
+	var bpmDuration = []struct {
+		bpm      int
+		duration time.Duration
+	}{
+		{120, 500 * time.Millisecond},
+		{60, time.Second},
+		{100, 600 * time.Millisecond},
+	}
+
+	for _, row := range bpmDuration {
+		actual := FromBPM(row.bpm)
+		if actual != row.duration {
+			t.Errorf("bpm: %d, actual: %v, expected: %v", row.bpm, actual, row.duration)
+		}
+	}
+}
+
+func TestSerialize(t *testing.T) {
 	p := &Piece{
-		// 440: *
-		// 523: *
+		Gain: 0.5,
 		Notes: []Note{
+			// 2:  **
+			// 1 : *  * ****
+			// 3:     *****
 			Note{
-				Frequency: 440,
+				Frequency: 1,
 				Duration:  frac.F(1, 2),
-				Start:     frac.N(0),
+				Start:     frac.F(0, 2),
 			},
 			Note{
-				Frequency: 523.25,
+				Frequency: 2,
+				Duration:  frac.F(2, 2),
+				Start:     frac.F(1, 2),
+			},
+			Note{
+				Frequency: 1,
 				Duration:  frac.F(1, 2),
-				Start:     frac.N(0),
+				Start:     frac.F(3, 2),
+			},
+			Note{
+				Frequency: 3,
+				Duration:  frac.F(1, 2),
+				Start:     frac.F(4, 2),
+				Volume:    -0.5,
+				Velocity:  64,
+			},
+			Note{
+				Frequency: 3,
+				Duration:  frac.F(4, 2),
+				Start:     frac.F(5, 2),
+			},
+			Note{
+				Frequency: 1,
+				Duration:  frac.F(4, 2),
+				Start:     frac.F(5, 2),
+			},
+			// no start, so it's the zero value
+			Note{
+				Frequency: 2,
+				Duration:  frac.N(1),
 			},
 		},
 	}
 
-	actual := p.intersectionBlocks()
-	expected := []block{
-		{start: frac.N(0), duration: frac.F(1, 2), frequencies: []float64{440, 523.25}},
+	buf, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("marshaling piece: %s", err)
+	}
+	actual := &Piece{}
+	if err := json.Unmarshal(buf, actual); err != nil {
+		t.Fatalf("unmarshaling json: %s", err)
+	}
+
+	if !actual.Equal(p) {
+		t.Fatalf("marshaling and then unmarshaling yields different result: \n%v\n%v", actual, p)
 	}
-	CompareBlocks(t, actual, expected)
+
 }
 
-func TestIntersectionContainingOverlap(t *testing.T) {
+func TestStreamerHeldNote(t *testing.T) {
 	p := &Piece{
-		//  * : 1/2 beat
-		// 440: *****
-		// 523:   **
+		// so that the sum never gets clipped
+		Gain: 0.5,
+		// 440: ***
+		// 523:  *
 		Notes: []Note{
 			Note{
 				Frequency: 440,
-				Duration:  frac.F(5, 2),
+				Duration:  frac.N(3),
 				Start:     frac.N(0),
 			},
 			Note{
@@ -51,246 +113,314 @@ func TestIntersectionContainingOverlap(t *testing.T) {
 			},
 		},
 	}
-	actual := p.intersectionBlocks()
-	expected := []block{
-		{start: frac.N(0), duration: frac.N(1), frequencies: []float64{440}},
-		{start: frac.N(1), duration: frac.N(1), frequencies: []float64{440, 523.25}},
-		{start: frac.N(2), duration: frac.F(1, 2), frequencies: []float64{440}},
+	sr := beep.SampleRate(44100)
+	beat := FromBPM(600)
+
+	actual := make([][2]float64, toSamples(sr, beat, frac.N(3)))
+	streamer, err := p.GetStreamer(sr, beat)
+	if err != nil {
+		t.Fatalf("getting streamer: %s", err)
+	}
+	// small odd buffers, so that the boundaries don't line up with the
+	// notes'
+	for i := 0; i < len(actual); i += 101 {
+		end := i + 101
+		if end > len(actual) {
+			end = len(actual)
+		}
+		if n, ok := streamer.Stream(actual[i:end]); !ok || n != end-i {
+			t.Fatalf("streamed %d samples (ok: %t), expected %d", n, ok, end-i)
+		}
+	}
+	if n, ok := streamer.Stream(make([][2]float64, 1)); ok || n != 0 {
+		t.Fatalf("streamer isn't drained at the end of the piece (n: %d)", n)
+	}
+
+	// the held note must be exactly the same wave as if it was played on its
+	// own, the other note just gets added on top
+	held := make([][2]float64, len(actual))
+	wave.NewOsc(sr, 440).Stream(held)
+	other := make([][2]float64, toSamples(sr, beat, frac.N(1)))
+	wave.NewOsc(sr, 523.25).Stream(other)
+	start := toSamples(sr, beat, frac.N(1))
+
+	for i := range actual {
+		expected := held[i][0] / 2
+		if i >= start && i < start+len(other) {
+			expected += other[i-start][0] / 2
+		}
+		if math.Abs(actual[i][0]-expected) > 1e-9 {
+			t.Fatalf("sample #%d, actual: %f, expected: %f", i, actual[i][0], expected)
+		}
 	}
-	CompareBlocks(t, actual, expected)
 }
 
-func TestIntersectionSilence(t *testing.T) {
+func TestAmplitude(t *testing.T) {
+	var rows = []struct {
+		volume    float64
+		velocity  int
+		amplitude float64
+	}{
+		{0, 0, 1},
+		{0, MaxVelocity, 1},
+		{-0.5, 0, 0.5},
+		{1, 0, 2},
+		{0, 63, 63.0 / 127},
+		{-0.5, 63, 63.0 / 127 / 2},
+		// can't go negative
+		{-3, 0, 0},
+		{0, -20, 0},
+		{1, -1, 0},
+		// can't go louder than the max velocity
+		{0, 200, 1},
+		{1, MaxVelocity + 1, 2},
+	}
+	for _, row := range rows {
+		note := Note{Volume: row.volume, Velocity: row.velocity}
+		actual := note.Amplitude()
+		if math.Abs(actual-row.amplitude) > 1e-9 {
+			t.Errorf("volume: %f, velocity: %d, actual: %f, expected: %f", row.volume, row.velocity, actual, row.amplitude)
+		}
+	}
+}
+
+func TestStreamerVelocity(t *testing.T) {
 	p := &Piece{
-		//  * : 1/3 beat
-		// 440:   *
-		// 523:     *****
+		// 440: .
 		Notes: []Note{
 			Note{
 				Frequency: 440,
-				Duration:  frac.F(1, 3),
-				Start:     frac.F(2, 3),
-			},
-			Note{
-				Frequency: 523.25,
-				Duration:  frac.F(5, 3),
-				Start:     frac.F(4, 3),
+				Duration:  frac.N(1),
+				Start:     frac.N(0),
+				Velocity:  32,
 			},
 		},
 	}
-	actual := p.intersectionBlocks()
-	expected := []block{
-		{start: frac.N(0), duration: frac.F(2, 3), frequencies: []float64{}},
-		{start: frac.F(2, 3), duration: frac.F(1, 3), frequencies: []float64{440}},
-		{start: frac.F(3, 3), duration: frac.F(1, 3), frequencies: []float64{}},
-		{start: frac.F(4, 3), duration: frac.F(5, 3), frequencies: []float64{523.25}},
+	sr := beep.SampleRate(44100)
+	beat := FromBPM(600)
+
+	actual := make([][2]float64, toSamples(sr, beat, frac.N(1)))
+	streamer, err := p.GetStreamer(sr, beat)
+	if err != nil {
+		t.Fatalf("getting streamer: %s", err)
+	}
+	streamer.Stream(actual)
+	expected := make([][2]float64, len(actual))
+	wave.NewOsc(sr, 440).Stream(expected)
+
+	for i := range actual {
+		if math.Abs(actual[i][0]-expected[i][0]*32/127) > 1e-9 {
+			t.Fatalf("sample #%d, actual: %f, expected: %f", i, actual[i][0], expected[i][0]*32/127)
+		}
 	}
-	CompareBlocks(t, actual, expected)
 }
-func TestIntersectionOverlap(t *testing.T) {
+
+func TestStreamerEnvelope(t *testing.T) {
 	p := &Piece{
-		//  * : 1/6 beat
-		// 440: *******
-		// 523:     *****
+		Envelope: wave.ADSR{Sustain: 1, Release: 50 * time.Millisecond},
+		// 440: *
+		// 523:  *
 		Notes: []Note{
 			Note{
 				Frequency: 440,
-				Duration:  frac.F(7, 6),
+				Duration:  frac.N(1),
 				Start:     frac.N(0),
 			},
 			Note{
 				Frequency: 523.25,
-				Duration:  frac.F(5, 6),
-				Start:     frac.F(4, 6),
+				Duration:  frac.N(1),
+				Start:     frac.N(1),
+				// no release, so the piece ends with the last note
+				Envelope: wave.ADSR{Sustain: 1},
 			},
 		},
 	}
-	actual := p.intersectionBlocks()
-	expected := []block{
-		{start: frac.N(0), duration: frac.F(4, 6), frequencies: []float64{440}},
-		{start: frac.F(4, 6), duration: frac.F(3, 6), frequencies: []float64{440, 523.25}},
-		{start: frac.F(7, 6), duration: frac.F(2, 6), frequencies: []float64{523.25}},
+	sr := beep.SampleRate(44100)
+	beat := FromBPM(600)
+
+	streamer, err := p.GetStreamer(sr, beat)
+	if err != nil {
+		t.Fatalf("getting streamer: %s", err)
+	}
+	buf := make([][2]float64, 512)
+	total := 0
+	for {
+		n, ok := streamer.Stream(buf)
+		total += n
+		if !ok {
+			break
+		}
+	}
+	expected := toSamples(sr, beat, frac.N(2))
+	if total != expected {
+		t.Fatalf("piece length, actual: %d, expected: %d", total, expected)
+	}
+
+	// the first note's release overlaps with the second note, but that
+	// doesn't make either of them quieter
+	streamer, err = p.GetStreamer(sr, beat)
+	if err != nil {
+		t.Fatalf("getting streamer: %s", err)
+	}
+	m := streamer.(*mix)
+	if m.gain != 1 {
+		t.Errorf("gain, actual: %f, expected: %f", m.gain, 1.0)
 	}
-	CompareBlocks(t, actual, expected)
 }
 
-func TestGetMarkersSimple(t *testing.T) {
+func TestStreamerClip(t *testing.T) {
 	p := &Piece{
-		//  * : 1 / 2 beat
-		// 440: ******
-		// 523:  ***
-		// 722:   *****
+		// 440: *
+		// 440: *
 		Notes: []Note{
 			Note{
 				Frequency: 440,
-				Duration:  frac.F(6, 2),
+				Duration:  frac.N(1),
 				Start:     frac.N(0),
 			},
 			Note{
-				Frequency: 523.25,
-				Duration:  frac.F(3, 2),
-				Start:     frac.F(1, 2),
-			},
-			Note{
-				Frequency: 722,
-				Duration:  frac.F(5, 2),
-				Start:     frac.F(2, 2),
+				Frequency: 440,
+				Duration:  frac.N(1),
+				Start:     frac.N(0),
 			},
 		},
 	}
+	sr := beep.SampleRate(44100)
+	beat := FromBPM(600)
 
-	actual := p.getMarkers()
-	expected := []frac.Frac{
-		frac.N(0),
-		frac.F(1, 2),
-		frac.F(2, 2),
-		frac.F(4, 2),
-		frac.F(6, 2),
-		frac.F(7, 2),
+	actual := make([][2]float64, toSamples(sr, beat, frac.N(1)))
+	streamer, err := p.GetStreamer(sr, beat)
+	if err != nil {
+		t.Fatalf("getting streamer: %s", err)
+	}
+	streamer.Stream(actual)
+	expected := make([][2]float64, len(actual))
+	wave.NewOsc(sr, 440).Stream(expected)
+	// both notes at full amplitude, clipped
+	for i := range actual {
+		e := math.Max(-1, math.Min(1, 2*expected[i][0]))
+		if math.Abs(actual[i][0]-e) > 1e-9 {
+			t.Fatalf("sample #%d, actual: %f, expected: %f", i, actual[i][0], e)
+		}
 	}
 
-	if len(actual) != len(expected) {
-		t.Fatalf("markers length don't match: \n(%d) %v\n(%d) %v", len(actual), actual, len(expected), expected)
+	// with a lower gain, it isn't clipped anymore
+	p.Gain = 0.5
+	streamer, err = p.GetStreamer(sr, beat)
+	if err != nil {
+		t.Fatalf("getting streamer: %s", err)
 	}
+	streamer.Stream(actual)
 	for i := range actual {
-		if actual[i] != expected[i] {
-			t.Fatalf("markers #%d doesn't match\n%v\n%v", i, actual, expected)
+		if math.Abs(actual[i][0]-expected[i][0]) > 1e-9 {
+			t.Fatalf("gain 0.5, sample #%d, actual: %f, expected: %f", i, actual[i][0], expected[i][0])
 		}
 	}
 }
 
-func TestGetMarkersDuplicates(t *testing.T) {
+func TestStreamerWaveform(t *testing.T) {
 	p := &Piece{
+		Waveform: wave.ShapeSaw,
+		// 440: *
 		Notes: []Note{
-			//  * : 1/3 beat
-			// 200:    *
-			// 349:   ***
-			// 440:  **
-			// 523:  ***
-			// notice how we don't start at 0
-			Note{
-				Frequency: 200,
-				Duration:  frac.F(1, 3),
-				Start:     frac.F(3, 3),
-			},
-			Note{
-				Frequency: 349.23,
-				Duration:  frac.F(3, 3),
-				Start:     frac.F(2, 3),
-			},
 			Note{
 				Frequency: 440,
-				Duration:  frac.F(2, 3),
-				Start:     frac.F(1, 3),
-			},
-			Note{
-				Frequency: 523.25,
-				Duration:  frac.F(3, 3),
-				Start:     frac.F(1, 3),
+				Duration:  frac.N(1),
+				Start:     frac.N(0),
 			},
 		},
 	}
+	sr := beep.SampleRate(44100)
+	beat := FromBPM(600)
 
-	actual := p.getMarkers()
-	expected := []frac.Frac{
-		frac.N(0),
-		frac.F(1, 3),
-		frac.F(2, 3),
-		frac.F(3, 3),
-		frac.F(4, 3),
-		frac.F(5, 3),
-	}
-
-	if len(actual) != len(expected) {
-		t.Fatalf("markers length don't match: \n(%d) %v\n(%d) %v", len(actual), actual, len(expected), expected)
+	actual := make([][2]float64, toSamples(sr, beat, frac.N(1)))
+	streamer, err := p.GetStreamer(sr, beat)
+	if err != nil {
+		t.Fatalf("getting streamer: %s", err)
 	}
+	streamer.Stream(actual)
+	expected := make([][2]float64, len(actual))
+	wave.NewSaw(sr, 440).Stream(expected)
 	for i := range actual {
 		if actual[i] != expected[i] {
-			t.Fatalf("markers #%d doesn't match\n%v\n%v", i, actual, expected)
+			t.Fatalf("sample #%d, actual: %v, expected: %v", i, actual[i], expected[i])
 		}
 	}
-}
 
-func TestFromBPM(t *testing.T) {
-	var bpmDuration = []struct {
-		bpm      int
-		duration time.Duration
-	}{
-		{120, 500 * time.Millisecond},
-		{60, time.Second},
-		{100, 600 * time.Millisecond},
+	// a pulse without a width is a square
+	p.Waveform = wave.ShapePulse
+	streamer, err = p.GetStreamer(sr, beat)
+	if err != nil {
+		t.Fatalf("getting streamer: %s", err)
 	}
-
-	for _, row := range bpmDuration {
-		actual := FromBPM(row.bpm)
-		if actual != row.duration {
-			t.Errorf("bpm: %d, actual: %v, expected: %v", row.bpm, actual, row.duration)
+	streamer.Stream(actual)
+	wave.NewSquare(sr, 440).Stream(expected)
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Fatalf("pulse sample #%d, actual: %v, expected: %v", i, actual[i], expected[i])
 		}
 	}
-}
 
-func CompareBlocks(t *testing.T, actual, expected []block) {
-	t.Helper()
-	if len(actual) != len(expected) {
-		t.Fatalf("intersection blocks length don't match: \n(%3d) %v\n(%3d) %v", len(actual), actual, len(expected), expected)
-	}
-	for i, block := range actual {
-		if !block.equal(expected[i]) {
-			t.Errorf("intersection block #%d doesn't match: \n%v\n%v", i, block, expected[i])
-		}
+	p.Waveform = "kazoo"
+	if _, err := p.GetStreamer(sr, beat); !errors.Is(err, wave.ErrUnknownShape) {
+		t.Errorf("unknown waveform, actual: %v, expected: %v", err, wave.ErrUnknownShape)
 	}
 }
 
-func TestSerialize(t *testing.T) {
+func TestStreamerOverflow(t *testing.T) {
+	const maxInt = int(^uint(0) >> 1)
 	p := &Piece{
+		// the end doesn't fit in a frac.Frac
 		Notes: []Note{
-			// 2:  **
-			// 1 : *  * ****
-			// 3:     *****
-			Note{
-				Frequency: 1,
-				Duration:  frac.F(1, 2),
-				Start:     frac.F(0, 2),
-			},
-			Note{
-				Frequency: 2,
-				Duration:  frac.F(2, 2),
-				Start:     frac.F(1, 2),
-			},
 			Note{
-				Frequency: 1,
-				Duration:  frac.F(1, 2),
-				Start:     frac.F(3, 2),
-			},
-			Note{
-				Frequency: 3,
-				Duration:  frac.F(1, 2),
-				Start:     frac.F(4, 2),
-			},
-			Note{
-				Frequency: 3,
-				Duration:  frac.F(4, 2),
-				Start:     frac.F(5, 2),
+				Frequency: 440,
+				Duration:  frac.N(maxInt),
+				Start:     frac.N(1),
 			},
+		},
+	}
+	sr := beep.SampleRate(44100)
+	beat := FromBPM(600)
+
+	if _, err := p.GetStreamer(sr, beat); !errors.Is(err, frac.ErrOverflow) {
+		t.Errorf("streamer, actual: %v, expected: %v", err, frac.ErrOverflow)
+	}
+	if err := p.WriteWAV(&bytes.Buffer{}, sr, beat, wav.Format{Channels: 1, Encoding: wav.PCM16}); !errors.Is(err, frac.ErrOverflow) {
+		t.Errorf("wav, actual: %v, expected: %v", err, frac.ErrOverflow)
+	}
+}
+
+func TestWriteWAV(t *testing.T) {
+	p := &Piece{
+		// 440: *
+		Notes: []Note{
 			Note{
-				Frequency: 1,
-				Duration:  frac.F(4, 2),
-				Start:     frac.F(5, 2),
+				Frequency: 440,
+				Duration:  frac.N(1),
+				Start:     frac.N(0),
 			},
 		},
 	}
+	sr := beep.SampleRate(44100)
+	beat := FromBPM(60)
 
-	buf, err := json.Marshal(p)
-	if err != nil {
-		t.Fatalf("marshaling piece: %s", err)
+	var buf bytes.Buffer
+	if err := p.WriteWAV(&buf, sr, beat, wav.Format{Channels: 1, Encoding: wav.PCM16}); err != nil {
+		t.Fatalf("writing wav: %s", err)
 	}
-	actual := &Piece{}
-	if err := json.Unmarshal(buf, actual); err != nil {
-		t.Fatalf("unmarshaling json: %s", err)
+	// 44 bytes of header, and one second of 16 bit mono
+	if buf.Len() != 44+44100*2 {
+		t.Errorf("size, actual: %d, expected: %d", buf.Len(), 44+44100*2)
 	}
+}
 
-	if !actual.Equal(p) {
-		t.Fatalf("marshaling and then unmarshaling yields different result: \n%v\n%v", actual, p)
+func TestRenderOverflow(t *testing.T) {
+	// the lowest common multiple of the denominators doesn't fit in an int
+	p := &Piece{}
+	for _, den := range []int{1000003, 1000033, 1000037, 1000039} {
+		p.Notes = append(p.Notes, Note{Frequency: 440, Duration: frac.N(1), Start: frac.F(1, den)})
+	}
+	if err := p.Render(); !errors.Is(err, frac.ErrOverflow) {
+		t.Errorf("actual: %v, expected: %v", err, frac.ErrOverflow)
 	}
-
 }
@@ -0,0 +1,122 @@
+package piece
+
+import (
+	"sort"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/math2001/piano/frac"
+)
+
+// a voice is a single note being played. The streamer is only asked for
+// samples between start and end, so it runs continuously for the whole
+// duration of the note.
+type voice struct {
+	start, end int // in samples
+	amplitude  float64
+	streamer   beep.Streamer
+}
+
+// mix sums up every voice, sample by sample
+type mix struct {
+	// sorted by start
+	voices []voice
+	pos    int
+	len    int
+	// gain is the same for every voice, and doesn't depend on the other
+	// notes. If it did, every note would jump in volume each time an other
+	// one starts or stops (or when one gets added to the piece).
+	gain float64
+	buf  [][2]float64
+}
+
+// init sorts the voices by start
+func (m *mix) init() {
+	sort.SliceStable(m.voices, func(i, j int) bool {
+		return m.voices[i].start < m.voices[j].start
+	})
+}
+
+func (m *mix) Stream(samples [][2]float64) (n int, ok bool) {
+	if m.pos >= m.len {
+		return 0, false
+	}
+	n = len(samples)
+	if m.pos+n > m.len {
+		n = m.len - m.pos
+	}
+	for i := range samples[:n] {
+		samples[i] = [2]float64{}
+	}
+
+	for _, v := range m.voices {
+		if v.start >= m.pos+n {
+			// sorted by start, so none of the next ones are playing either
+			break
+		}
+		// the part of this voice that overlaps with [pos, pos+n)
+		from, to := v.start, v.end
+		if from < m.pos {
+			from = m.pos
+		}
+		if to > m.pos+n {
+			to = m.pos + n
+		}
+		if from >= to {
+			continue
+		}
+		if cap(m.buf) < to-from {
+			m.buf = make([][2]float64, to-from)
+		}
+		buf := m.buf[:to-from]
+		filled := fill(v.streamer, buf)
+		k := v.amplitude * m.gain
+		for i := range buf[:filled] {
+			samples[from-m.pos+i][0] += buf[i][0] * k
+			samples[from-m.pos+i][1] += buf[i][1] * k
+		}
+	}
+
+	// the voices don't get scaled down when there are many of them, so
+	// chords can go over 1
+	for i := range samples[:n] {
+		samples[i][0] = clip(samples[i][0])
+		samples[i][1] = clip(samples[i][1])
+	}
+
+	m.pos += n
+	return n, true
+}
+
+func (m *mix) Err() error {
+	return nil
+}
+
+// clip keeps x between -1 and 1
+func clip(x float64) float64 {
+	if x > 1 {
+		return 1
+	} else if x < -1 {
+		return -1
+	}
+	return x
+}
+
+// fill streams from s until buf is full or s is drained. It returns how many
+// samples were written
+func fill(s beep.Streamer, buf [][2]float64) int {
+	filled := 0
+	for filled < len(buf) {
+		n, ok := s.Stream(buf[filled:])
+		filled += n
+		if !ok {
+			break
+		}
+	}
+	return filled
+}
+
+// toSamples converts a time in beats to a number of samples
+func toSamples(sr beep.SampleRate, beat time.Duration, f frac.Frac) int {
+	return sr.N(time.Duration(float64(beat) * f.Float()))
+}
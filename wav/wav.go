@@ -0,0 +1,175 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/faiface/beep"
+)
+
+var ErrFormat = errors.New("unsupported format")
+
+// Encoding is how each sample is stored
+type Encoding int
+
+const (
+	PCM16 Encoding = iota + 1
+	PCM24
+	Float32
+)
+
+// Format describes the kind of WAV file to write
+type Format struct {
+	// Channels is 1 (mono, both channels are averaged) or 2 (stereo)
+	Channels int
+	Encoding Encoding
+}
+
+// bytes returns the number of bytes per sample for one channel
+func (f Format) bytes() int {
+	switch f.Encoding {
+	case PCM16:
+		return 2
+	case PCM24:
+		return 3
+	case Float32:
+		return 4
+	}
+	return 0
+}
+
+func (f Format) validate() error {
+	if f.Channels != 1 && f.Channels != 2 {
+		return fmt.Errorf("%d channels (need 1 or 2): %w", f.Channels, ErrFormat)
+	}
+	if f.bytes() == 0 {
+		return fmt.Errorf("encoding %d: %w", f.Encoding, ErrFormat)
+	}
+	return nil
+}
+
+// the format tags in the fmt chunk
+const (
+	tagPCM   = 1
+	tagFloat = 3
+)
+
+// Encode streams s until it's drained, and writes it to w as a WAV file.
+// The samples are buffered in memory, because the RIFF header needs to know
+// the size of the data before it, and w doesn't have to be seekable. So don't
+// give it a streamer which never drains!
+func Encode(w io.Writer, s beep.Streamer, sr beep.SampleRate, f Format) error {
+	if err := f.validate(); err != nil {
+		return err
+	}
+
+	var data bytes.Buffer
+	buf := make([][2]float64, 512)
+	frame := make([]byte, f.Channels*f.bytes())
+	for {
+		n, ok := s.Stream(buf)
+		for _, sample := range buf[:n] {
+			if f.Channels == 1 {
+				encodeSample(frame, (sample[0]+sample[1])/2, f.Encoding)
+			} else {
+				encodeSample(frame, sample[0], f.Encoding)
+				encodeSample(frame[f.bytes():], sample[1], f.Encoding)
+			}
+			data.Write(frame)
+		}
+		if !ok {
+			break
+		}
+	}
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("streaming: %w", err)
+	}
+
+	if _, err := w.Write(header(sr, f, data.Len())); err != nil {
+		return fmt.Errorf("writing header: %w", err)
+	}
+	if data.Len()%2 == 1 {
+		data.WriteByte(0)
+	}
+	if _, err := data.WriteTo(w); err != nil {
+		return fmt.Errorf("writing data: %w", err)
+	}
+	return nil
+}
+
+// header returns everything that goes before the samples
+func header(sr beep.SampleRate, f Format, size int) []byte {
+	var h bytes.Buffer
+	le := binary.LittleEndian
+	write := func(v interface{}) {
+		// can't fail writing to a bytes.Buffer
+		binary.Write(&h, le, v)
+	}
+
+	blockAlign := f.Channels * f.bytes()
+	frames := size / blockAlign
+
+	// the float format isn't plain PCM, so its fmt chunk has an (empty)
+	// extension and it needs a fact chunk
+	fmtSize := 16
+	tag := tagPCM
+	if f.Encoding == Float32 {
+		fmtSize = 18
+		tag = tagFloat
+	}
+
+	riffSize := 4 + (8 + fmtSize) + (8 + size)
+	if f.Encoding == Float32 {
+		riffSize += 8 + 4
+	}
+	// chunks have to be an even number of bytes (only matters for mono 24 bit)
+	pad := size % 2
+	riffSize += pad
+
+	h.WriteString("RIFF")
+	write(uint32(riffSize))
+	h.WriteString("WAVE")
+
+	h.WriteString("fmt ")
+	write(uint32(fmtSize))
+	write(uint16(tag))
+	write(uint16(f.Channels))
+	write(uint32(sr))
+	write(uint32(int(sr) * blockAlign)) // bytes per second
+	write(uint16(blockAlign))
+	write(uint16(f.bytes() * 8)) // bits per sample
+	if f.Encoding == Float32 {
+		write(uint16(0)) // size of the extension
+		h.WriteString("fact")
+		write(uint32(4))
+		write(uint32(frames))
+	}
+
+	h.WriteString("data")
+	write(uint32(size))
+	return h.Bytes()
+}
+
+// encodeSample writes v (clamped between -1 and 1) at the start of b
+func encodeSample(b []byte, v float64, e Encoding) {
+	if v > 1 {
+		v = 1
+	} else if v < -1 {
+		v = -1
+	}
+	switch e {
+	case PCM16:
+		binary.LittleEndian.PutUint16(b, uint16(int16(math.Round(v*math.MaxInt16))))
+	case PCM24:
+		n := int32(math.Round(v * (1<<23 - 1)))
+		b[0] = byte(n)
+		b[1] = byte(n >> 8)
+		b[2] = byte(n >> 16)
+	case Float32:
+		binary.LittleEndian.PutUint32(b, math.Float32bits(float32(v)))
+	}
+}
@@ -0,0 +1,134 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/faiface/beep"
+)
+
+// ramp streams n samples going from -1 to 1, with the right channel reversed
+type ramp struct {
+	pos, n int
+}
+
+func (r *ramp) Stream(samples [][2]float64) (int, bool) {
+	if r.pos >= r.n {
+		return 0, false
+	}
+	i := 0
+	for ; i < len(samples) && r.pos < r.n; i++ {
+		v := float64(r.pos)/float64(r.n-1)*2 - 1
+		samples[i] = [2]float64{v, -v}
+		r.pos++
+	}
+	return i, true
+}
+
+func (r *ramp) Err() error { return nil }
+
+func TestEncodePCM(t *testing.T) {
+	var rows = []struct {
+		format    Format
+		tolerance float64
+	}{
+		{Format{Channels: 2, Encoding: PCM16}, 1.0 / (1 << 14)},
+		{Format{Channels: 2, Encoding: PCM24}, 1.0 / (1 << 22)},
+		{Format{Channels: 1, Encoding: PCM16}, 1.0 / (1 << 14)},
+		// odd number of bytes in the data chunk
+		{Format{Channels: 1, Encoding: PCM24}, 1.0 / (1 << 22)},
+	}
+	for _, row := range rows {
+		var buf bytes.Buffer
+		if err := Encode(&buf, &ramp{n: 1001}, 44100, row.format); err != nil {
+			t.Fatalf("format: %v, encoding: %s", row.format, err)
+		}
+		if (buf.Len()-8) != int(binary.LittleEndian.Uint32(buf.Bytes()[4:])) || buf.Len()%2 != 0 {
+			t.Errorf("format: %v, riff size, actual: %d, expected: %d", row.format, binary.LittleEndian.Uint32(buf.Bytes()[4:]), buf.Len()-8)
+		}
+
+		b := buf.Bytes()
+		le := binary.LittleEndian
+		if le.Uint16(b[20:]) != tagPCM || int(le.Uint16(b[22:])) != row.format.Channels || le.Uint32(b[24:]) != 44100 || int(le.Uint16(b[34:])) != row.format.bytes()*8 {
+			t.Errorf("format: %v, fmt chunk: % x", row.format, b[20:36])
+		}
+		if string(b[36:40]) != "data" || int(le.Uint32(b[40:])) != 1001*row.format.Channels*row.format.bytes() {
+			t.Fatalf("format: %v, data chunk: % x", row.format, b[36:44])
+		}
+
+		// (beep's decoder doesn't do stereo 24 bit properly)
+		actual := decodePCM(b[44:], row.format, 1001)
+		expected := make([][2]float64, 1001)
+		(&ramp{n: 1001}).Stream(expected)
+		for i := range actual {
+			e := expected[i]
+			if row.format.Channels == 1 {
+				// the channels are averaged, and the ramps are opposite
+				e = [2]float64{0, 0}
+			}
+			if math.Abs(actual[i][0]-e[0]) > row.tolerance || math.Abs(actual[i][1]-e[1]) > row.tolerance {
+				t.Fatalf("format: %v, sample #%d, actual: %v, expected: %v", row.format, i, actual[i], e)
+			}
+		}
+	}
+}
+
+// decodePCM reads n frames of PCM data
+func decodePCM(b []byte, f Format, n int) [][2]float64 {
+	samples := make([][2]float64, n)
+	size := f.bytes()
+	for i := range samples {
+		for c := 0; c < f.Channels; c++ {
+			p := b[(i*f.Channels+c)*size:]
+			if f.Encoding == PCM16 {
+				samples[i][c] = float64(int16(binary.LittleEndian.Uint16(p))) / math.MaxInt16
+			} else {
+				v := int32(p[0]) | int32(p[1])<<8 | int32(int8(p[2]))<<16
+				samples[i][c] = float64(v) / (1<<23 - 1)
+			}
+		}
+	}
+	return samples
+}
+
+func TestEncodeFloat(t *testing.T) {
+	var buf bytes.Buffer
+	f := Format{Channels: 2, Encoding: Float32}
+	if err := Encode(&buf, &ramp{n: 3}, 48000, f); err != nil {
+		t.Fatalf("encoding: %s", err)
+	}
+	b := buf.Bytes()
+	le := binary.LittleEndian
+
+	if string(b[0:4]) != "RIFF" || string(b[8:12]) != "WAVE" || string(b[12:16]) != "fmt " {
+		t.Fatalf("invalid header: %q", b[:16])
+	}
+	if le.Uint16(b[20:]) != tagFloat || le.Uint16(b[22:]) != 2 || le.Uint32(b[24:]) != 48000 || le.Uint16(b[34:]) != 32 {
+		t.Errorf("fmt chunk: % x", b[20:36])
+	}
+	if string(b[38:42]) != "fact" || le.Uint32(b[46:]) != 3 {
+		t.Errorf("fact chunk: % x", b[38:50])
+	}
+	if string(b[50:54]) != "data" || le.Uint32(b[54:]) != 3*2*4 {
+		t.Fatalf("data chunk: % x", b[50:58])
+	}
+	var actual [6]float32
+	binary.Read(bytes.NewReader(b[58:]), le, &actual)
+	expected := [6]float32{-1, 1, 0, 0, 1, -1}
+	if actual != expected {
+		t.Errorf("samples, actual: %v, expected: %v", actual, expected)
+	}
+}
+
+func TestEncodeInvalidFormat(t *testing.T) {
+	for _, f := range []Format{{Channels: 3, Encoding: PCM16}, {Channels: 2}} {
+		err := Encode(ioutil.Discard, beep.Silence(1), 44100, f)
+		if !errors.Is(err, ErrFormat) {
+			t.Errorf("format: %v, actual: %v, expected: %v", f, err, ErrFormat)
+		}
+	}
+}
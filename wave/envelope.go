@@ -0,0 +1,106 @@
+package wave
+
+import (
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// ADSR describes the shape of an envelope. The zero value means "no
+// envelope" (see IsZero)
+type ADSR struct {
+	// Attack is how long it takes to go from silence to full amplitude
+	Attack time.Duration `json:"attack"`
+	// Decay is how long it takes to go from full amplitude to Sustain
+	Decay time.Duration `json:"decay"`
+	// Sustain is the level (between 0 and 1) held after the decay, until the
+	// note is released
+	Sustain float64 `json:"sustain"`
+	// Release is how long it takes to fade out once the note is released
+	Release time.Duration `json:"release"`
+}
+
+// IsZero reports whether a is the zero value. On its own, it would just be
+// silence, so it's used to mean that no envelope has been chosen
+func (a ADSR) IsZero() bool {
+	return a == ADSR{}
+}
+
+// Envelope shapes the amplitude of a streamer following an ADSR. It plays
+// attack, decay and sustain for gate samples, and then releases. It drains
+// once the release is over.
+type Envelope struct {
+	streamer beep.Streamer
+
+	attack, decay, release int // in samples
+	sustain                float64
+	gate                   int
+
+	pos int
+}
+
+// NewEnvelope wraps s into an envelope. gate is the number of samples before
+// the release starts (typically the duration of the note), so the envelope
+// lasts for gate + adsr.Release
+func NewEnvelope(sr beep.SampleRate, adsr ADSR, gate int, s beep.Streamer) *Envelope {
+	return &Envelope{
+		streamer: s,
+		attack:   sr.N(adsr.Attack),
+		decay:    sr.N(adsr.Decay),
+		sustain:  adsr.Sustain,
+		release:  sr.N(adsr.Release),
+		gate:     gate,
+	}
+}
+
+// level returns the amplitude of the envelope at the sample pos
+func (e *Envelope) level(pos int) float64 {
+	if pos >= e.gate {
+		// we might be released in the middle of the attack or the decay, so
+		// fade out from wherever we got to
+		if pos-e.gate >= e.release {
+			return 0
+		}
+		return e.level(e.gate-1) * (1 - float64(pos-e.gate)/float64(e.release))
+	}
+	if pos < 0 {
+		return 0
+	}
+	if pos < e.attack {
+		return float64(pos) / float64(e.attack)
+	}
+	if pos-e.attack < e.decay {
+		return 1 - (1-e.sustain)*float64(pos-e.attack)/float64(e.decay)
+	}
+	return e.sustain
+}
+
+func (e *Envelope) Stream(samples [][2]float64) (n int, ok bool) {
+	if e.pos >= e.Len() {
+		return 0, false
+	}
+	if len(samples) > e.Len()-e.pos {
+		samples = samples[:e.Len()-e.pos]
+	}
+	n, ok = e.streamer.Stream(samples)
+	for i := range samples[:n] {
+		k := e.level(e.pos + i)
+		samples[i][0] *= k
+		samples[i][1] *= k
+	}
+	e.pos += n
+	return n, ok
+}
+
+func (e *Envelope) Err() error {
+	return e.streamer.Err()
+}
+
+// Len returns the total number of samples, including the release
+func (e *Envelope) Len() int {
+	return e.gate + e.release
+}
+
+func (e *Envelope) Position() int {
+	return e.pos
+}
@@ -0,0 +1,116 @@
+package wave
+
+import (
+	"math/bits"
+	"math/rand"
+)
+
+// The noise generators all take a seed: the same seed always gives the same
+// samples, so they can be used in tests. They never drain, and both channels
+// get the same value.
+
+// White is white noise: every sample is independent and uniformly
+// distributed between -1 and 1 (flat spectrum)
+type White struct {
+	rng *rand.Rand
+}
+
+func NewWhite(seed int64) *White {
+	return &White{rng: rand.New(rand.NewSource(seed))}
+}
+
+func (w *White) next() float64 {
+	return w.rng.Float64()*2 - 1
+}
+
+func (w *White) Stream(samples [][2]float64) (n int, ok bool) {
+	return stream(samples, w.next)
+}
+
+func (w *White) Err() error {
+	return nil
+}
+
+// pinkRows is the number of white noise generators summed up by Pink. Each
+// one covers an octave
+const pinkRows = 16
+
+// Pink is pink noise (-3dB per octave), using the Voss-McCartney algorithm:
+// we sum up pinkRows white noise values, where the i-th one only changes
+// every 2^i samples (plus one which changes every sample).
+type Pink struct {
+	white   *White
+	rows    [pinkRows]float64
+	sum     float64
+	counter uint32
+}
+
+func NewPink(seed int64) *Pink {
+	p := &Pink{white: NewWhite(seed)}
+	for i := range p.rows {
+		p.rows[i] = p.white.next()
+		p.sum += p.rows[i]
+	}
+	return p
+}
+
+func (p *Pink) next() float64 {
+	p.counter++
+	// exactly one row changes every sample (except once every 2^pinkRows),
+	// the number of trailing zeros picks which one
+	if i := bits.TrailingZeros32(p.counter); i < pinkRows {
+		p.sum -= p.rows[i]
+		p.rows[i] = p.white.next()
+		p.sum += p.rows[i]
+	}
+	return (p.sum + p.white.next()) / (pinkRows + 1)
+}
+
+func (p *Pink) Stream(samples [][2]float64) (n int, ok bool) {
+	return stream(samples, p.next)
+}
+
+func (p *Pink) Err() error {
+	return nil
+}
+
+// brownStep is the largest amount Brown can move by in one sample
+const brownStep = 0.02
+
+// Brown is brown noise (-6dB per octave): it's white noise integrated, ie. a
+// random walk. It bounces off -1 and 1 so that it stays in range.
+type Brown struct {
+	white *White
+	value float64
+}
+
+func NewBrown(seed int64) *Brown {
+	return &Brown{white: NewWhite(seed)}
+}
+
+func (b *Brown) next() float64 {
+	b.value += b.white.next() * brownStep
+	if b.value > 1 {
+		b.value = 2 - b.value
+	} else if b.value < -1 {
+		b.value = -2 - b.value
+	}
+	return b.value
+}
+
+func (b *Brown) Stream(samples [][2]float64) (n int, ok bool) {
+	return stream(samples, b.next)
+}
+
+func (b *Brown) Err() error {
+	return nil
+}
+
+func stream(samples [][2]float64, next func() float64) (n int, ok bool) {
+	for i := range samples {
+		v := next()
+		samples[i][0] = v
+		samples[i][1] = v
+	}
+	return len(samples), true
+}
@@ -0,0 +1,75 @@
+package wave
+
+import (
+	"math"
+
+	"github.com/faiface/beep"
+)
+
+// Osc is an oscillator which keeps track of its phase as a float instead of
+// looping over a precomputed period. That means it can play any frequency,
+// not just the ones which have a whole number of samples per period.
+type Osc struct {
+	// phase is where we are in the current period, between 0 and 1
+	phase float64
+	// step is how much the phase moves forward for every sample (freq / sr)
+	step float64
+	pos  int
+	// shape gives the value of the wave at a phase. It gets the step as well
+	// so that it can smooth out the discontinuities (see shapes.go)
+	shape func(phase, step float64) float64
+}
+
+// NewOsc returns a sine oscillator playing freq (in Hz) at the sample rate
+// sr. It never drains, so wrap it in a beep.Take to give it a duration
+func NewOsc(sr beep.SampleRate, freq float64) *Osc {
+	return newOsc(sr, freq, sine)
+}
+
+func newOsc(sr beep.SampleRate, freq float64, shape func(phase, step float64) float64) *Osc {
+	return &Osc{
+		step:  freq / float64(sr),
+		shape: shape,
+	}
+}
+
+func (o *Osc) Stream(target [][2]float64) (n int, ok bool) {
+	for i := range target {
+		v := o.shape(o.phase, o.step)
+		target[i][0] = v
+		target[i][1] = v
+		o.phase += o.step
+		if o.phase >= 1 {
+			o.phase -= math.Floor(o.phase)
+		}
+	}
+	o.pos += len(target)
+	return len(target), true
+}
+
+func (o *Osc) Err() error {
+	return nil
+}
+
+// Len returns the number of samples in one period, rounded to the nearest
+// sample. The oscillator itself never ends.
+func (o *Osc) Len() int {
+	if o.step == 0 {
+		return 0
+	}
+	return int(math.Round(1 / o.step))
+}
+
+// Position returns the number of samples streamed since the start
+func (o *Osc) Position() int {
+	return o.pos
+}
+
+// Seek moves to the pos-th sample since the start
+func (o *Osc) Seek(pos int) error {
+	o.pos = pos
+	// don't accumulate the phase, we want to land exactly on the sample
+	o.phase = float64(pos) * o.step
+	o.phase -= math.Floor(o.phase)
+	return nil
+}
@@ -0,0 +1,141 @@
+package wave
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/faiface/beep"
+)
+
+var ErrUnknownShape = errors.New("unknown shape")
+var ErrPulseWidth = errors.New("invalid pulse width")
+
+// Shape names a waveform. It's a string so that it reads nicely in JSON
+type Shape string
+
+const (
+	ShapeSine     Shape = "sine"
+	ShapeSquare   Shape = "square"
+	ShapeSaw      Shape = "saw"
+	ShapeTriangle Shape = "triangle"
+	ShapePulse    Shape = "pulse"
+)
+
+// NewShape returns an oscillator with the given shape. width is only used by
+// ShapePulse (see NewPulse): 0 means 0.5 (a square), otherwise it has to be
+// strictly between 0 and 1. An empty shape is a sine.
+func NewShape(sr beep.SampleRate, freq float64, shape Shape, width float64) (*Osc, error) {
+	switch shape {
+	case "", ShapeSine:
+		return NewOsc(sr, freq), nil
+	case ShapeSquare:
+		return NewSquare(sr, freq), nil
+	case ShapeSaw:
+		return NewSaw(sr, freq), nil
+	case ShapeTriangle:
+		return NewTriangle(sr, freq), nil
+	case ShapePulse:
+		if width == 0 {
+			width = 0.5
+		}
+		if !(width > 0 && width < 1) {
+			return nil, fmt.Errorf("%f (needs to be between 0 and 1): %w", width, ErrPulseWidth)
+		}
+		return NewPulse(sr, freq, width), nil
+	}
+	return nil, fmt.Errorf("%q: %w", shape, ErrUnknownShape)
+}
+
+// The naive square, saw and pulse waves jump instantly, which creates
+// harmonics way above the Nyquist frequency. They fold back into audible
+// (and out of tune) aliasing, especially on high notes. PolyBLEP fixes that
+// by smoothing the sample right before and the one right after each jump
+// with a polynomial (a cheap band-limited step). The triangle doesn't jump
+// but its slope does, so it uses the integrated version (PolyBLAMP).
+
+// NewSquare returns a band-limited square wave oscillator
+func NewSquare(sr beep.SampleRate, freq float64) *Osc {
+	return NewPulse(sr, freq, 0.5)
+}
+
+// NewPulse returns a band-limited pulse wave oscillator. width is the
+// fraction of the period spent at 1 (the rest is at -1), between 0 and 1.
+// A width of 0.5 is a square wave.
+func NewPulse(sr beep.SampleRate, freq float64, width float64) *Osc {
+	return newOsc(sr, freq, func(phase, step float64) float64 {
+		return pulse(phase, step, width)
+	})
+}
+
+// NewSaw returns a band-limited sawtooth wave oscillator, rising from -1 to 1
+func NewSaw(sr beep.SampleRate, freq float64) *Osc {
+	return newOsc(sr, freq, saw)
+}
+
+// NewTriangle returns a band-limited triangle wave oscillator
+func NewTriangle(sr beep.SampleRate, freq float64) *Osc {
+	return newOsc(sr, freq, triangle)
+}
+
+func sine(phase, step float64) float64 {
+	return math.Sin(2 * math.Pi * phase)
+}
+
+func saw(phase, step float64) float64 {
+	// jumps down by 2 at phase 0
+	return 2*phase - 1 - 2*blep(phase, step)
+}
+
+func pulse(phase, step, width float64) float64 {
+	v := -1.0
+	if phase < width {
+		v = 1
+	}
+	// jumps up by 2 at phase 0, and down by 2 at width
+	return v + 2*blep(phase, step) - 2*blep(wrap(phase-width), step)
+}
+
+func triangle(phase, step float64) float64 {
+	// rises from -1 at phase 0 to 1 at phase 0.5, and falls back
+	v := 4*phase - 1
+	if phase >= 0.5 {
+		v = 3 - 4*phase
+	}
+	// the slope goes from -4 to 4 (per period) at 0, and back at 0.5. That's
+	// a change of 8*step per sample
+	return v + 8*step*(blamp(phase, step)-blamp(wrap(phase-0.5), step))
+}
+
+// blep returns the correction for a step of height 1 happening at phase 0.
+// It's only non-zero for the sample before and the one after.
+func blep(phase, step float64) float64 {
+	if phase < step {
+		// just after the step
+		t := phase/step - 1
+		return -t * t / 2
+	} else if phase > 1-step {
+		// just before the step
+		t := (phase-1)/step + 1
+		return t * t / 2
+	}
+	return 0
+}
+
+// blamp returns the correction for the slope changing by 1 per sample at
+// phase 0 (it's the integral of blep).
+func blamp(phase, step float64) float64 {
+	if phase < step {
+		t := 1 - phase/step
+		return t * t * t / 6
+	} else if phase > 1-step {
+		t := (phase-1)/step + 1
+		return t * t * t / 6
+	}
+	return 0
+}
+
+// wrap brings the phase back between 0 and 1
+func wrap(phase float64) float64 {
+	return phase - math.Floor(phase)
+}
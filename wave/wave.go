@@ -1,19 +1,16 @@
 package wave
 
 import (
-	"time"
+	"math"
 
 	"github.com/faiface/beep"
 )
 
 // N returns, based on the sample rate (typically of the speaker) and the
 // wave's desired frequency, the number of samples needed for one period to
-// complete
+// complete. It has to be rounded to a whole number of samples, so use an Osc
+// if you need an accurate pitch.
 func N(sr beep.SampleRate, freq float64) int {
 	// T * f = 1
-	period := time.Second / time.Duration(freq)
-
-	samplesPerPeriod := sr.N(period)
-
-	return samplesPerPeriod
+	return int(math.Round(float64(sr) / freq))
 }
@@ -1,6 +1,13 @@
 package wave
 
-import "testing"
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+)
 
 func TestSine(t *testing.T) {
 	// make sure all the values are between -1 and 1
@@ -8,3 +15,292 @@ func TestSine(t *testing.T) {
 	// make sure that when we make multiple call to streamer.Stream with
 	// varying buffer size, we still get a repeating sine wave
 }
+
+func TestOscFrequency(t *testing.T) {
+	sr := beep.SampleRate(44100)
+	// 523.25 used to be truncated to 523 and then rounded to a whole number of
+	// samples per period
+	for _, freq := range []float64{440, 523.25, 3729.31} {
+		osc := NewOsc(sr, freq)
+		// stream with varying buffer sizes to make sure the phase carries over
+		buf := make([][2]float64, sr.N(time.Second))
+		for i := 0; i < len(buf); i += 37 {
+			end := i + 37
+			if end > len(buf) {
+				end = len(buf)
+			}
+			osc.Stream(buf[i:end])
+		}
+		for x, sample := range buf {
+			expected := math.Sin(2 * math.Pi * freq * float64(x) / float64(sr))
+			if math.Abs(sample[0]-expected) > 1e-6 || sample[0] != sample[1] {
+				t.Fatalf("freq: %f, sample #%d, actual: %v, expected: %f", freq, x, sample, expected)
+			}
+		}
+	}
+}
+
+func TestOscSeek(t *testing.T) {
+	sr := beep.SampleRate(44100)
+	osc := NewOsc(sr, 523.25)
+	buf := make([][2]float64, 1000)
+	osc.Stream(buf)
+
+	if err := osc.Seek(500); err != nil {
+		t.Fatalf("seeking: %s", err)
+	}
+	actual := make([][2]float64, 500)
+	osc.Stream(actual)
+	for i := range actual {
+		if math.Abs(actual[i][0]-buf[500+i][0]) > 1e-9 {
+			t.Fatalf("sample #%d after seek, actual: %f, expected: %f", i, actual[i][0], buf[500+i][0])
+		}
+	}
+}
+
+// ones always streams 1s, so that we can look at an envelope directly
+type ones struct{}
+
+func (ones) Stream(samples [][2]float64) (int, bool) {
+	for i := range samples {
+		samples[i] = [2]float64{1, 1}
+	}
+	return len(samples), true
+}
+
+func (ones) Err() error { return nil }
+
+func TestEnvelope(t *testing.T) {
+	// 1 sample per millisecond
+	sr := beep.SampleRate(1000)
+	adsr := ADSR{
+		Attack:  10 * time.Millisecond,
+		Decay:   10 * time.Millisecond,
+		Sustain: 0.5,
+		Release: 20 * time.Millisecond,
+	}
+	env := NewEnvelope(sr, adsr, 40, ones{})
+	if env.Len() != 60 {
+		t.Fatalf("length, actual: %d, expected: %d", env.Len(), 60)
+	}
+
+	buf := make([][2]float64, 100)
+	n, _ := env.Stream(buf)
+	if n != 60 {
+		t.Fatalf("streamed samples, actual: %d, expected: %d", n, 60)
+	}
+	if n, ok := env.Stream(buf); n != 0 || ok {
+		t.Fatalf("envelope isn't drained after the release (n: %d)", n)
+	}
+
+	levels := map[int]float64{
+		0:  0,
+		5:  0.5,
+		10: 1,
+		15: 0.75,
+		20: 0.5,
+		39: 0.5,
+		40: 0.5,
+		50: 0.25,
+		59: 0.025,
+	}
+	for pos, expected := range levels {
+		if math.Abs(buf[pos][0]-expected) > 1e-9 {
+			t.Errorf("level at %d, actual: %f, expected: %f", pos, buf[pos][0], expected)
+		}
+	}
+}
+
+func TestEnvelopeEarlyRelease(t *testing.T) {
+	sr := beep.SampleRate(1000)
+	adsr := ADSR{
+		Attack:  20 * time.Millisecond,
+		Sustain: 1,
+		Release: 10 * time.Millisecond,
+	}
+	// released halfway through the attack: it should fade out from there
+	// instead of jumping
+	env := NewEnvelope(sr, adsr, 10, ones{})
+	buf := make([][2]float64, env.Len())
+	env.Stream(buf)
+	for i := 1; i < len(buf); i++ {
+		if math.Abs(buf[i][0]-buf[i-1][0]) > 0.051 {
+			t.Fatalf("jump between %d and %d: %f -> %f", i-1, i, buf[i-1][0], buf[i][0])
+		}
+	}
+}
+
+func TestShapesAliasing(t *testing.T) {
+	sr := beep.SampleRate(44100)
+	// we look at exactly n samples, with a frequency such that the
+	// harmonics land on the multiples of the m-th DFT bin. Everything on the
+	// other bins is the harmonics above Nyquist folding back (aliasing).
+	n, m := 441, 28
+	freq := float64(sr) * float64(m) / float64(n) // 2800 Hz
+
+	var rows = []struct {
+		shape Shape
+		// naive is the wave without any anti-aliasing
+		naive func(phase float64) float64
+	}{
+		{ShapeSaw, func(p float64) float64 { return 2*p - 1 }},
+		{ShapeSquare, func(p float64) float64 {
+			if p < 0.5 {
+				return 1
+			}
+			return -1
+		}},
+		{ShapeTriangle, func(p float64) float64 {
+			if p < 0.5 {
+				return 4*p - 1
+			}
+			return 3 - 4*p
+		}},
+	}
+
+	for _, row := range rows {
+		osc, err := NewShape(sr, freq, row.shape, 0)
+		if err != nil {
+			t.Fatalf("shape %q: %s", row.shape, err)
+		}
+		buf := make([][2]float64, n)
+		osc.Stream(buf)
+
+		actual := make([]float64, n)
+		naive := make([]float64, n)
+		for x := range buf {
+			actual[x] = buf[x][0]
+			naive[x] = row.naive(wrap(freq * float64(x) / float64(sr)))
+		}
+		// should get rid of the vast majority of it
+		if aliasing(actual, m) > aliasing(naive, m)/10 {
+			t.Errorf("shape %q, actual aliasing: %f, naive aliasing: %f", row.shape, aliasing(actual, m), aliasing(naive, m))
+		}
+	}
+}
+
+// aliasing returns the energy of the DFT bins which aren't multiples of m
+func aliasing(buf []float64, m int) float64 {
+	n := len(buf)
+	energy := 0.0
+	for k := 1; k < n/2; k++ {
+		if k%m == 0 {
+			continue
+		}
+		var re, im float64
+		for x, v := range buf {
+			angle := 2 * math.Pi * float64(k*x%n) / float64(n)
+			re += v * math.Cos(angle)
+			im -= v * math.Sin(angle)
+		}
+		energy += re*re + im*im
+	}
+	return energy
+}
+
+func TestPulseWidth(t *testing.T) {
+	sr := beep.SampleRate(44100)
+	// low enough for the smoothing not to matter much
+	osc := NewPulse(sr, 44.1, 0.25)
+	buf := make([][2]float64, 1000)
+	osc.Stream(buf)
+
+	high := 0
+	for _, sample := range buf {
+		if sample[0] > 0 {
+			high++
+		}
+	}
+	if high < 249 || high > 251 {
+		t.Errorf("samples above 0, actual: %d, expected: %d", high, 250)
+	}
+}
+
+func TestPulseDefaultWidth(t *testing.T) {
+	sr := beep.SampleRate(44100)
+	osc, err := NewShape(sr, 440, ShapePulse, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := make([][2]float64, 1000)
+	osc.Stream(actual)
+	expected := make([][2]float64, len(actual))
+	NewSquare(sr, 440).Stream(expected)
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Fatalf("sample #%d, actual: %v, expected: %v", i, actual[i], expected[i])
+		}
+	}
+
+	for _, width := range []float64{-0.5, 1, 1.5, math.NaN()} {
+		if _, err := NewShape(sr, 440, ShapePulse, width); !errors.Is(err, ErrPulseWidth) {
+			t.Errorf("width %f, actual: %v, expected: %v", width, err, ErrPulseWidth)
+		}
+	}
+}
+
+func TestUnknownShape(t *testing.T) {
+	_, err := NewShape(beep.SampleRate(44100), 440, "kazoo", 0)
+	if !errors.Is(err, ErrUnknownShape) {
+		t.Errorf("actual: %v, expected: %v", err, ErrUnknownShape)
+	}
+}
+
+func TestNoise(t *testing.T) {
+	var rows = []struct {
+		name string
+		new  func(seed int64) beep.Streamer
+		// lag-1 autocorrelation: the "redder" the noise, the more each sample
+		// looks like the previous one
+		minCorr, maxCorr float64
+	}{
+		{"white", func(seed int64) beep.Streamer { return NewWhite(seed) }, -0.05, 0.05},
+		{"pink", func(seed int64) beep.Streamer { return NewPink(seed) }, 0.5, 0.95},
+		{"brown", func(seed int64) beep.Streamer { return NewBrown(seed) }, 0.99, 1},
+	}
+
+	for _, row := range rows {
+		a := make([][2]float64, 44100)
+		b := make([][2]float64, 44100)
+		other := make([][2]float64, 44100)
+		row.new(1).Stream(a)
+		// in different chunks, shouldn't make a difference
+		s := row.new(1)
+		s.Stream(b[:1000])
+		s.Stream(b[1000:])
+		row.new(2).Stream(other)
+
+		same := true
+		for i := range a {
+			if a[i] != b[i] {
+				t.Fatalf("%s: same seed, sample #%d: %v != %v", row.name, i, a[i], b[i])
+			}
+			if a[i] != other[i] {
+				same = false
+			}
+			if a[i][0] < -1 || a[i][0] > 1 || a[i][0] != a[i][1] {
+				t.Fatalf("%s: sample #%d out of range: %v", row.name, i, a[i])
+			}
+		}
+		if same {
+			t.Errorf("%s: different seeds give the same samples", row.name)
+		}
+
+		var mean float64
+		for i := range a {
+			mean += a[i][0]
+		}
+		mean /= float64(len(a))
+		var num, den float64
+		for i := range a {
+			den += (a[i][0] - mean) * (a[i][0] - mean)
+			if i > 0 {
+				num += (a[i][0] - mean) * (a[i-1][0] - mean)
+			}
+		}
+		corr := num / den
+		if corr < row.minCorr || corr > row.maxCorr {
+			t.Errorf("%s: autocorrelation, actual: %f, expected: between %f and %f", row.name, corr, row.minCorr, row.maxCorr)
+		}
+	}
+}